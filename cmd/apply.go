@@ -17,14 +17,22 @@ func init() {
 	rootCmd.AddCommand(applyCmd)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
+	addSourceRefFlag(applyCmd)
+	addEntryFilterFlags(applyCmd)
 }
 
 func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
+	if c.SourceVCS.WarnOnApply {
+		if status, err := c.getSourceStatus(); err == nil && !status.InSync() {
+			cmd.Printf("warning: source directory is not in sync: %s\n", status)
+		}
+	}
+
 	persistentState, err := c.getPersistentState(nil)
 	if err != nil {
 		return err
 	}
 	defer persistentState.Close()
 
-	return c.applyArgs(args, persistentState)
+	return c.applyArgs(args, persistentState, false)
 }
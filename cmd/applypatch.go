@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+var applyPatchCmd = &cobra.Command{
+	Use:     "apply-patch [patch]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Apply a git patch to the destination directory",
+	Long:    mustGetLongHelp("apply-patch"),
+	Example: getExample("apply-patch"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runApplyPatchCmd,
+}
+
+// A patchedFile is the result of applying a git.FilePatch to the destination
+// directory.
+type patchedFile struct {
+	filePatch *git.FilePatch
+	oldPath   string
+	newPath   string
+	oldPerm   os.FileMode
+	oldData   []byte
+	newData   []byte
+}
+
+func init() {
+	rootCmd.AddCommand(applyPatchCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(applyPatchCmd, 1)
+}
+
+func (c *Config) runApplyPatchCmd(cmd *cobra.Command, args []string) error {
+	var r io.Reader = c.Stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := c.fs.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	filePatches, err := git.ParsePatch(r)
+	if err != nil {
+		return err
+	}
+
+	// Check that every file patch applies before changing anything. git
+	// patches change the type of a file by deleting it and then creating it,
+	// so track the paths deleted by earlier file patches.
+	patchedFiles := make([]*patchedFile, 0, len(filePatches))
+	removed := make(map[string]struct{})
+	for _, filePatch := range filePatches {
+		patchedFile, err := c.applyFilePatch(filePatch, removed)
+		if err != nil {
+			return err
+		}
+		if filePatch.IsDelete || filePatch.IsRename {
+			removed[patchedFile.oldPath] = struct{}{}
+		}
+		patchedFiles = append(patchedFiles, patchedFile)
+	}
+
+	for _, patchedFile := range patchedFiles {
+		if err := c.writePatchedFile(patchedFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyFilePatch returns the result of applying filePatch to the destination
+// directory, without modifying it. Paths in removed are treated as not
+// existing.
+func (c *Config) applyFilePatch(filePatch *git.FilePatch, removed map[string]struct{}) (*patchedFile, error) {
+	patchedFile := &patchedFile{
+		filePatch: filePatch,
+		oldPath:   filepath.Join(c.DestDir, filepath.FromSlash(filePatch.OldPath)),
+		newPath:   filepath.Join(c.DestDir, filepath.FromSlash(filePatch.NewPath)),
+	}
+	for _, path := range []string{patchedFile.oldPath, patchedFile.newPath} {
+		if contains, err := vfs.Contains(c.fs, path, c.DestDir); err != nil {
+			return nil, err
+		} else if !contains {
+			return nil, fmt.Errorf("%s: outside destination directory", path)
+		}
+	}
+
+	info, err := c.fs.Lstat(patchedFile.oldPath)
+	if _, ok := removed[patchedFile.oldPath]; ok {
+		info, err = nil, &os.PathError{Op: "lstat", Path: patchedFile.oldPath, Err: os.ErrNotExist}
+	}
+	switch {
+	case filePatch.IsNew && err == nil:
+		return nil, fmt.Errorf("%s: already exists", patchedFile.oldPath)
+	case filePatch.IsNew && os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case filePatch.OldMode == filemode.Symlink && info.Mode()&os.ModeType != os.ModeSymlink:
+		return nil, fmt.Errorf("%s: not a symlink", patchedFile.oldPath)
+	case filePatch.OldMode == filemode.Symlink:
+		linkname, err := c.fs.Readlink(patchedFile.oldPath)
+		if err != nil {
+			return nil, err
+		}
+		patchedFile.oldData = []byte(linkname)
+	case !info.Mode().IsRegular():
+		return nil, fmt.Errorf("%s: not a regular file", patchedFile.oldPath)
+	default:
+		patchedFile.oldPerm = info.Mode().Perm()
+		if patchedFile.oldData, err = c.fs.ReadFile(patchedFile.oldPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if filePatch.IsRename && patchedFile.newPath != patchedFile.oldPath {
+		if _, err := c.fs.Lstat(patchedFile.newPath); err == nil {
+			if _, ok := removed[patchedFile.newPath]; !ok {
+				return nil, fmt.Errorf("%s: already exists", patchedFile.newPath)
+			}
+		}
+	}
+
+	if patchedFile.newData, err = filePatch.Apply(patchedFile.oldData); err != nil {
+		return nil, err
+	}
+	return patchedFile, nil
+}
+
+// writePatchedFile writes patchedFile to the destination directory.
+func (c *Config) writePatchedFile(patchedFile *patchedFile) error {
+	filePatch := patchedFile.filePatch
+	if filePatch.IsDelete {
+		return c.mutator.RemoveAll(patchedFile.oldPath)
+	}
+
+	if filePatch.IsRename && patchedFile.newPath != patchedFile.oldPath {
+		if err := vfs.MkdirAll(c.mutator, filepath.Dir(patchedFile.newPath), 0o777&^os.FileMode(c.Umask)); err != nil {
+			return err
+		}
+		if err := c.mutator.Rename(patchedFile.oldPath, patchedFile.newPath); err != nil {
+			return err
+		}
+	}
+
+	if filePatch.NewMode == filemode.Symlink {
+		if !filePatch.IsNew && filePatch.OldMode == filemode.Symlink && string(patchedFile.oldData) == string(patchedFile.newData) {
+			return nil
+		}
+		if !filePatch.IsNew {
+			if err := c.mutator.RemoveAll(patchedFile.newPath); err != nil {
+				return err
+			}
+		}
+		if err := vfs.MkdirAll(c.mutator, filepath.Dir(patchedFile.newPath), 0o777&^os.FileMode(c.Umask)); err != nil {
+			return err
+		}
+		return c.mutator.WriteSymlink(string(patchedFile.newData), patchedFile.newPath)
+	}
+
+	perm := os.FileMode(0o666)
+	if filePatch.NewMode == filemode.Executable {
+		perm = 0o777
+	}
+	perm &^= os.FileMode(c.Umask)
+	if !filePatch.IsNew && filePatch.OldMode != filemode.Symlink {
+		// Git patches only record whether a file is executable, so keep the
+		// existing permissions and only change the executable bits, setting
+		// them where the file is readable.
+		perm = patchedFile.oldPerm
+		switch {
+		case filePatch.OldMode == filePatch.NewMode:
+		case filePatch.NewMode == filemode.Executable:
+			perm |= perm & 0o444 >> 2
+		default:
+			perm &^= 0o111
+		}
+	}
+
+	switch {
+	case filePatch.OldMode == filemode.Symlink:
+		if err := c.mutator.RemoveAll(patchedFile.newPath); err != nil {
+			return err
+		}
+		return c.mutator.WriteFile(patchedFile.newPath, patchedFile.newData, perm, nil)
+	case filePatch.IsNew:
+		if err := vfs.MkdirAll(c.mutator, filepath.Dir(patchedFile.newPath), 0o777&^os.FileMode(c.Umask)); err != nil {
+			return err
+		}
+		return c.mutator.WriteFile(patchedFile.newPath, patchedFile.newData, perm, nil)
+	case string(patchedFile.oldData) != string(patchedFile.newData):
+		return c.mutator.WriteFile(patchedFile.newPath, patchedFile.newData, perm, patchedFile.oldData)
+	case filePatch.OldMode != filePatch.NewMode:
+		return c.mutator.Chmod(patchedFile.newPath, perm)
+	default:
+		return nil
+	}
+}
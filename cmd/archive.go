@@ -31,9 +31,15 @@ func init() {
 	persistentFlags := archiveCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.archive.output, "output", "o", "", "output filename")
 	panicOnError(archiveCmd.MarkPersistentFlagFilename("output"))
+	addOverrideDataFlags(archiveCmd)
+	addSourceRefFlag(archiveCmd)
+	addEntryFilterFlags(archiveCmd)
 }
 
 func (c *Config) runArchiveCmd(cmd *cobra.Command, args []string) error {
+	if err := c.ensureRenderTrusted(); err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// builtinVCSCommand is the sourceVCS.command that selects the builtin git
+// implementation.
+const builtinVCSCommand = "builtin"
+
+// A builtinGitVCS is a git VCS implemented in-process with go-git, so that no
+// git binary is needed. Its VCS methods return nil as it does not run
+// commands.
+type builtinGitVCS struct{}
+
+// A builtinGitLoader loads repositories for file:// remotes, which can be
+// either bare repositories or working directories.
+type builtinGitLoader struct{}
+
+func init() {
+	// go-git's file transport runs git-upload-pack and git-receive-pack, so
+	// serve file:// remotes in-process instead.
+	client.InstallProtocol("file", server.NewServer(builtinGitLoader{}))
+}
+
+func (builtinGitLoader) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	s, err := server.DefaultLoader.Load(ep)
+	if err != transport.ErrRepositoryNotFound {
+		return s, err
+	}
+	gitDirEndpoint := *ep
+	gitDirEndpoint.Path = filepath.Join(ep.Path, gogit.GitDirName)
+	return server.DefaultLoader.Load(&gitDirEndpoint)
+}
+
+func (builtinGitVCS) AddArgs(path string) []string {
+	return nil
+}
+
+func (builtinGitVCS) CloneArgs(repo, dir string) []string {
+	return nil
+}
+
+func (builtinGitVCS) CommitArgs(message string) []string {
+	return nil
+}
+
+func (builtinGitVCS) InitArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) ParseStatusOutput(output []byte) (interface{}, error) {
+	return nil, nil
+}
+
+func (builtinGitVCS) PullArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) PushArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) StatusArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) UpdateSubmodulesArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) VersionArgs() []string {
+	return nil
+}
+
+func (builtinGitVCS) VersionRegexp() *regexp.Regexp {
+	return nil
+}
+
+// AddAll stages all changes in the worktree in dir, including removals.
+func (builtinGitVCS) AddAll(dir string) error {
+	worktree, err := openBuiltinGitWorktree(dir)
+	if err != nil {
+		return err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+	for path, fileStatus := range status {
+		switch fileStatus.Worktree {
+		case gogit.Unmodified:
+		case gogit.Deleted:
+			if _, err := worktree.Remove(path); err != nil {
+				return err
+			}
+		default:
+			if _, err := worktree.Add(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Clone clones url into dir. If branch is not empty then only branch is
+// cloned. If depth is not zero then the clone is shallow.
+func (builtinGitVCS) Clone(url, dir, branch string, depth int) error {
+	cloneOptions := &gogit.CloneOptions{
+		URL:               url,
+		Depth:             depth,
+		RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+	}
+	if branch != "" {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		cloneOptions.SingleBranch = true
+	}
+	_, err := gogit.PlainClone(dir, false, cloneOptions)
+	return err
+}
+
+// Commit commits the staged changes in dir with message, using the author
+// from the git config.
+func (builtinGitVCS) Commit(dir, message string) error {
+	worktree, err := openBuiltinGitWorktree(dir)
+	if err != nil {
+		return err
+	}
+	_, err = worktree.Commit(message, &gogit.CommitOptions{})
+	return err
+}
+
+// Init creates a new repository in dir.
+func (builtinGitVCS) Init(dir string) error {
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		return err
+	}
+	// Write the config, like git init does, so that the repository can be
+	// used as a file:// remote.
+	config, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	return repo.Storer.SetConfig(config)
+}
+
+// Pull fast-forwards the repository in dir from its origin remote.
+func (builtinGitVCS) Pull(dir string) error {
+	worktree, err := openBuiltinGitWorktree(dir)
+	if err != nil {
+		return err
+	}
+	if err := worktree.Pull(&gogit.PullOptions{}); err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// Push pushes the repository in dir to its origin remote.
+func (builtinGitVCS) Push(dir string) error {
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+	if err := repo.Push(&gogit.PushOptions{}); err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// SourceID returns the origin remote URL and the commit of rev in the
+// repository that contains dir. It returns empty strings if dir is not in a
+// repository or the repository has no origin remote.
+func (builtinGitVCS) SourceID(dir, rev string) (string, string, error) {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	switch {
+	case err == gogit.ErrRepositoryNotExists:
+		return "", "", nil
+	case err != nil:
+		return "", "", err
+	}
+	remote, err := repo.Remote(gogit.DefaultRemoteName)
+	switch {
+	case err == gogit.ErrRemoteNotFound:
+		return "", "", nil
+	case err != nil:
+		return "", "", err
+	}
+	var url string
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		url = urls[0]
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", "", err
+	}
+	return url, hash.String(), nil
+}
+
+// Status returns the status of the repository in dir in the same form as
+// git.ParseStatusPorcelainV2.
+func (builtinGitVCS) Status(dir string) (*git.Status, error) {
+	worktree, err := openBuiltinGitWorktree(dir)
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(status))
+	for path := range status {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	result := &git.Status{}
+	for _, path := range paths {
+		fileStatus := status[path]
+		x := builtinGitStatusCode(fileStatus.Staging)
+		y := builtinGitStatusCode(fileStatus.Worktree)
+		switch {
+		case fileStatus.Worktree == gogit.Untracked:
+			result.Untracked = append(result.Untracked, git.UntrackedStatus{
+				Path: path,
+			})
+		case fileStatus.Staging == gogit.UpdatedButUnmerged || fileStatus.Worktree == gogit.UpdatedButUnmerged:
+			result.Unmerged = append(result.Unmerged, git.UnmergedStatus{
+				X:    x,
+				Y:    y,
+				Path: path,
+			})
+		case fileStatus.Staging == gogit.Renamed || fileStatus.Staging == gogit.Copied:
+			result.RenamedOrCopied = append(result.RenamedOrCopied, git.RenamedOrCopiedStatus{
+				X:        x,
+				Y:        y,
+				RC:       x,
+				Path:     path,
+				OrigPath: fileStatus.Extra,
+			})
+		case x == '.' && y == '.':
+		default:
+			result.Ordinary = append(result.Ordinary, git.OrdinaryStatus{
+				X:    x,
+				Y:    y,
+				Path: path,
+			})
+		}
+	}
+	return result, nil
+}
+
+// builtinGitStatusCode returns the porcelain v2 status code for statusCode.
+func builtinGitStatusCode(statusCode gogit.StatusCode) byte {
+	if statusCode == gogit.Unmodified {
+		return '.'
+	}
+	return byte(statusCode)
+}
+
+func openBuiltinGitWorktree(dir string) (*gogit.Worktree, error) {
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Worktree()
+}
+
+// runBuiltinVCS runs f, which does the equivalent of git with argv in-process,
+// respecting --dry-run and --verbose.
+func (c *Config) runBuiltinVCS(f func() error, argv ...string) error {
+	if c.Verbose {
+		words := []string{"git"}
+		for _, arg := range argv {
+			words = append(words, chezmoi.MaybeShellQuote(arg))
+		}
+		fmt.Fprintln(c.Stdout, strings.Join(words, " "))
+	}
+	if c.DryRun {
+		return nil
+	}
+	return f()
+}
+
+// builtinAutoCommit commits all changes in the source directory with
+// builtinVCS.
+func (c *Config) builtinAutoCommit(builtinVCS builtinGitVCS, command string, args []string) error {
+	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return err
+	}
+	if err := c.runBuiltinVCS(func() error {
+		return builtinVCS.AddAll(rawSourceDir)
+	}, "add", "."); err != nil {
+		return err
+	}
+	status, err := builtinVCS.Status(rawSourceDir)
+	if err != nil {
+		return err
+	}
+	if status.Empty() {
+		// There is nothing to commit.
+		return nil
+	}
+	commitMessage, err := c.getCommitMessage(status, command, args)
+	if err != nil {
+		return err
+	}
+	return c.runBuiltinVCS(func() error {
+		return builtinVCS.Commit(rawSourceDir, commitMessage)
+	}, "commit", "--message", commitMessage)
+}
@@ -22,9 +22,14 @@ func init() {
 	rootCmd.AddCommand(catCmd)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(catCmd, 1)
+	addOverrideDataFlags(catCmd)
+	addSourceRefFlag(catCmd)
 }
 
 func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
+	if err := c.ensureRenderTrusted(); err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -33,5 +33,10 @@ func (c *Config) runCDCmd(cmd *cobra.Command, args []string) error {
 	if shellCommand == "" {
 		shellCommand, _ = shell.CurrentUserShell()
 	}
-	return c.run(c.SourceDir, shellCommand, c.CD.Args...)
+	env, cleanup, err := c.getScriptEnv()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return c.runWithEnv(c.SourceDir, env, shellCommand, c.CD.Args...)
 }
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// A commitMessageData is the data passed to commit message templates. The
+// fields of the parsed status, for example .Ordinary, are available directly.
+type commitMessageData struct {
+	*git.Status
+	Command string
+	Prefix  string
+	Targets []commitMessageTarget
+}
+
+// A commitMessageTarget describes a target passed to the command that caused a
+// commit.
+type commitMessageTarget struct {
+	Path       string
+	SourcePath string
+	Attributes []string
+}
+
+// getCommitMessage returns the commit message for status, the parsed status of
+// the source directory, after command was run with args.
+func (c *Config) getCommitMessage(status interface{}, command string, args []string) (string, error) {
+	data := &commitMessageData{
+		Command: command,
+		Prefix:  c.SourceVCS.CommitMessagePrefixes[command],
+		Targets: c.getCommitMessageTargets(args),
+	}
+	if gitStatus, ok := status.(*git.Status); ok && gitStatus != nil {
+		data.Status = gitStatus
+	} else {
+		data.Status = &git.Status{}
+	}
+
+	commitMessageText, builtin, err := c.getCommitMessageTemplate()
+	if err != nil {
+		return "", err
+	}
+	commitMessageTmpl, err := template.New("commit_message").Funcs(c.templateFuncs).Parse(commitMessageText)
+	if err != nil {
+		return "", err
+	}
+	sb := &strings.Builder{}
+	if err := commitMessageTmpl.Execute(sb, data); err != nil {
+		return "", err
+	}
+	commitMessage := sb.String()
+	// Custom templates decide where to put the prefix themselves.
+	if builtin && data.Prefix != "" {
+		commitMessage = data.Prefix + ": " + commitMessage
+	}
+
+	if c.SourceVCS.EditCommitMessage {
+		return c.editCommitMessage(commitMessage)
+	}
+	return commitMessage, nil
+}
+
+// getCommitMessageTemplate returns the commit message template and whether it
+// is the builtin template.
+func (c *Config) getCommitMessageTemplate() (string, bool, error) {
+	switch {
+	case c.SourceVCS.CommitMessageTemplate != "" && c.SourceVCS.CommitMessageTemplateFile != "":
+		return "", false, errors.New("sourceVCS.commitMessageTemplate and sourceVCS.commitMessageTemplateFile cannot both be set")
+	case c.SourceVCS.CommitMessageTemplate != "":
+		return c.SourceVCS.CommitMessageTemplate, false, nil
+	case c.SourceVCS.CommitMessageTemplateFile != "":
+		data, err := c.fs.ReadFile(filepath.Join(c.SourceDir, c.SourceVCS.CommitMessageTemplateFile))
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), false, nil
+	default:
+		data, err := getAsset(commitMessageTemplateAsset)
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), true, nil
+	}
+}
+
+// getCommitMessageTargets returns the targets for args. Only the source
+// entries of the targets are read, so that committing does not render the
+// target state. The source path and attributes of targets that are no longer
+// in the source state, for example after chezmoi forget, are empty.
+func (c *Config) getCommitMessageTargets(args []string) []commitMessageTarget {
+	if len(args) == 0 {
+		return nil
+	}
+	targets := make([]commitMessageTarget, 0, len(args))
+	for _, arg := range args {
+		targetPath, err := filepath.Abs(arg)
+		if err != nil {
+			continue
+		}
+		target := commitMessageTarget{
+			Path: targetPath,
+		}
+		// The targets are only informational, so ignore any errors reading
+		// the source state.
+		if targetName, err := filepath.Rel(c.DestDir, targetPath); err == nil && !strings.HasPrefix(targetName, "..") {
+			target.Path = targetName
+			if entry, err := chezmoi.FindSourceEntry(c.fs, c.SourceDir, targetName); err == nil {
+				target.SourcePath = entry.SourceName()
+				target.Attributes = entryAttributes(entry)
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// editCommitMessage lets the user edit commitMessage with their editor.
+func (c *Config) editCommitMessage(commitMessage string) (string, error) {
+	f, err := ioutil.TempFile("", "chezmoi-commit-message-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.WriteString(commitMessage); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := c.runEditor(f.Name()); err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return "", errors.New("empty commit message, not committing")
+	}
+	return string(data), nil
+}
+
+// entryAttributes returns the names of entry's attributes.
+func entryAttributes(entry chezmoi.Entry) []string {
+	var attributes []string
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		if entry.Exact {
+			attributes = append(attributes, "exact")
+		}
+		if entry.Perm&0o77 == 0 {
+			attributes = append(attributes, "private")
+		}
+	case *chezmoi.File:
+		if entry.Empty {
+			attributes = append(attributes, "empty")
+		}
+		if entry.Encrypted {
+			attributes = append(attributes, "encrypted")
+		}
+		if entry.Perm&0o111 != 0 {
+			attributes = append(attributes, "executable")
+		}
+		if entry.Perm&0o77 == 0 {
+			attributes = append(attributes, "private")
+		}
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+	case *chezmoi.Script:
+		attributes = append(attributes, "script")
+		if entry.Once {
+			attributes = append(attributes, "once")
+		}
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+	case *chezmoi.Symlink:
+		attributes = append(attributes, "symlink")
+		if entry.Template {
+			attributes = append(attributes, "template")
+		}
+	}
+	return attributes
+}
@@ -2,18 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/Masterminds/sprig"
@@ -28,17 +32,26 @@ import (
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
-const commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+const (
+	commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+	scriptLogsDirName          = "logs"
+)
 
 var whitespaceRegexp = regexp.MustCompile(`\s+`)
 
 type sourceVCSConfig struct {
-	Command    string
-	AutoCommit bool
-	AutoPush   bool
-	Init       interface{}
-	NotGit     bool
-	Pull       interface{}
+	Command                   string
+	AutoCommit                bool
+	AutoPush                  bool
+	Backends                  map[string]configVCSConfig
+	CommitMessagePrefixes     map[string]string
+	CommitMessageTemplate     string
+	CommitMessageTemplateFile string
+	EditCommitMessage         bool
+	Init                      interface{}
+	NotGit                    bool
+	Pull                      interface{}
+	WarnOnApply               bool
 }
 
 type templateConfig struct {
@@ -47,58 +60,81 @@ type templateConfig struct {
 
 // A Config represents a configuration.
 type Config struct {
-	configFile        string
-	err               error
-	fs                vfs.FS
-	mutator           chezmoi.Mutator
-	SourceDir         string
-	DestDir           string
-	Umask             permValue
-	DryRun            bool
-	Follow            bool
-	Remove            bool
-	Verbose           bool
-	Color             string
-	Debug             bool
-	GPG               chezmoi.GPG
-	GPGRecipient      string
-	SourceVCS         sourceVCSConfig
-	Template          templateConfig
-	Merge             mergeConfig
-	Bitwarden         bitwardenCmdConfig
-	CD                cdCmdConfig
-	Diff              diffCmdConfig
-	GenericSecret     genericSecretCmdConfig
-	Gopass            gopassCmdConfig
-	KeePassXC         keePassXCCmdConfig
-	Lastpass          lastpassCmdConfig
-	Onepassword       onepasswordCmdConfig
-	Vault             vaultCmdConfig
-	Pass              passCmdConfig
-	Data              map[string]interface{}
-	colored           bool
-	maxDiffDataSize   int
-	templateFuncs     template.FuncMap
-	add               addCmdConfig
-	archive           archiveCmdConfig
-	completion        completionCmdConfig
-	data              dataCmdConfig
-	dump              dumpCmdConfig
-	edit              editCmdConfig
-	executeTemplate   executeTemplateCmdConfig
-	_import           importCmdConfig
-	init              initCmdConfig
-	keyring           keyringCmdConfig
-	managed           managedCmdConfig
-	purge             purgeCmdConfig
-	remove            removeCmdConfig
-	update            updateCmdConfig
-	upgrade           upgradeCmdConfig
-	Stdin             io.Reader
-	Stdout            io.Writer
-	Stderr            io.Writer
-	bds               *xdg.BaseDirectorySpecification
-	scriptStateBucket []byte
+	configFile              string
+	err                     error
+	fs                      vfs.FS
+	mutator                 chezmoi.Mutator
+	SourceDir               string
+	DestDir                 string
+	Umask                   permValue
+	DryRun                  bool
+	Follow                  bool
+	Remove                  bool
+	Verbose                 bool
+	Color                   string
+	Debug                   bool
+	GPG                     chezmoi.GPG
+	GPGRecipient            string
+	Interpreters            map[string]chezmoi.Interpreter
+	ScriptEnv               map[string]string
+	ScriptDataFile          bool
+	ScriptErrors            string
+	ScriptLogs              int
+	ScriptTimeout           time.Duration
+	SourceVCS               sourceVCSConfig
+	Template                templateConfig
+	Merge                   mergeConfig
+	Bitwarden               bitwardenCmdConfig
+	CD                      cdCmdConfig
+	Diff                    diffCmdConfig
+	GenericSecret           genericSecretCmdConfig
+	Gopass                  gopassCmdConfig
+	KeePassXC               keePassXCCmdConfig
+	Lastpass                lastpassCmdConfig
+	Onepassword             onepasswordCmdConfig
+	Vault                   vaultCmdConfig
+	Pass                    passCmdConfig
+	Update                  updateCmdConfig
+	Data                    map[string]interface{}
+	dataFile                string
+	overrideData            []string
+	sourceRef               string
+	entryInclude            []string
+	entryExclude            []string
+	entryGlobs              []string
+	colored                 bool
+	maxDiffDataSize         int
+	templateFuncs           template.FuncMap
+	execTemplateFuncNames   []string
+	noScripts               bool
+	triggerOutput           io.Writer
+	add                     addCmdConfig
+	archive                 archiveCmdConfig
+	completion              completionCmdConfig
+	data                    dataCmdConfig
+	dump                    dumpCmdConfig
+	edit                    editCmdConfig
+	executeTemplate         executeTemplateCmdConfig
+	history                 historyCmdConfig
+	_import                 importCmdConfig
+	init                    initCmdConfig
+	keyring                 keyringCmdConfig
+	managed                 managedCmdConfig
+	purge                   purgeCmdConfig
+	remove                  removeCmdConfig
+	sourceStatus            sourceStatusCmdConfig
+	test                    testCmdConfig
+	upgrade                 upgradeCmdConfig
+	Stdin                   io.Reader
+	Stdout                  io.Writer
+	Stderr                  io.Writer
+	stdinReader             *bufio.Reader
+	bds                     *xdg.BaseDirectorySpecification
+	configStateBucket       []byte
+	entryStateBucket        []byte
+	scriptStateBucket       []byte
+	trustStateBucket        []byte
+	trustedSourceID         string
 }
 
 // A configOption sets an option on a Config.
@@ -148,12 +184,16 @@ func newConfig(options ...configOption) *Config {
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
+		ScriptErrors: "abort",
 		GPG: chezmoi.GPG{
 			Command: "gpg",
 		},
 		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
 		templateFuncs:     sprig.TxtFuncMap(),
+		configStateBucket: []byte("configState"),
+		entryStateBucket:  []byte("entryState"),
 		scriptStateBucket: []byte("script"),
+		trustStateBucket:  []byte("trust"),
 		Stdin:             os.Stdin,
 		Stdout:            os.Stdout,
 		Stderr:            os.Stderr,
@@ -164,6 +204,37 @@ func newConfig(options ...configOption) *Config {
 	return c
 }
 
+// addSourceRefFlag adds a flag to cmd to read the source state from a git
+// revision.
+func addSourceRefFlag(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVar(&config.sourceRef, "source-ref", "", "read the source state from a git revision")
+}
+
+// addEntryFilterFlags adds flags to cmd to filter entries by type and target
+// name.
+func addEntryFilterFlags(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.entryInclude, "include", "i", []string{"all"}, "include entry types")
+	addEntryExcludeFlags(cmd)
+}
+
+// addEntryExcludeFlags adds flags to cmd to exclude entries by type and target
+// name.
+func addEntryExcludeFlags(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.entryExclude, "exclude", "x", nil, "exclude entry types")
+	persistentFlags.StringArrayVar(&config.entryGlobs, "glob", nil, "only include targets matching glob")
+}
+
+// addOverrideDataFlags adds flags to cmd to override the template data.
+func addOverrideDataFlags(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVar(&config.dataFile, "data-file", "", "read template data overrides from file")
+	panicOnError(cmd.MarkPersistentFlagFilename("data-file", "json", "toml", "yaml", "yml"))
+	persistentFlags.StringArrayVar(&config.overrideData, "override-data", nil, "override template data with key=value or key:=json")
+}
+
 func (c *Config) addTemplateFunc(key string, value interface{}) {
 	if c.templateFuncs == nil {
 		c.templateFuncs = make(template.FuncMap)
@@ -174,39 +245,166 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 	c.templateFuncs[key] = value
 }
 
-func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
+// addExecTemplateFunc adds a template function that runs commands or reads
+// secrets. These functions require the source state to be trusted and are
+// disabled by --no-scripts.
+func (c *Config) addExecTemplateFunc(key string, value interface{}) {
+	c.addTemplateFunc(key, value)
+	c.execTemplateFuncNames = append(c.execTemplateFuncNames, key)
+}
+
+// applyArgs applies the targets in args, or all targets if args is empty, with
+// c.mutator. If readOnly is true then the caller only inspects the changes, as
+// diff and verify do, and nothing is recorded in persistentState.
+func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState, readOnly bool) error {
+	if err := c.ensureSourceTrusted(persistentState, readOnly); err != nil {
+		return err
+	}
 	fs := vfs.NewReadOnlyFS(c.fs)
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
+	scriptEnv, cleanup, err := c.getScriptEnv()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	switch c.ScriptErrors {
+	case "abort", "continue", "prompt":
+	default:
+		return fmt.Errorf("%s: invalid scriptErrors, want abort, continue, or prompt", c.ScriptErrors)
+	}
+	var scriptErrs []error
 	applyOptions := &chezmoi.ApplyOptions{
-		DestDir:           ts.DestDir,
-		DryRun:            c.DryRun,
-		Ignore:            ts.TargetIgnore.Match,
-		PersistentState:   persistentState,
-		Remove:            c.Remove,
+		DestDir:         ts.DestDir,
+		DryRun:          c.DryRun,
+		Ignore:          ts.Ignore,
+		NoScripts:       c.noScripts,
+		PersistentState: persistentState,
+		Remove:          c.Remove,
+		ScriptEnv:       scriptEnv,
+		ScriptError: func(s *chezmoi.Script, err error) error {
+			switch c.ScriptErrors {
+			case "continue":
+			case "prompt":
+				choice, promptErr := c.prompt(fmt.Sprintf("%v, continue", err), "yn")
+				if promptErr != nil {
+					return promptErr
+				}
+				if choice == 'n' {
+					return err
+				}
+			default:
+				return err
+			}
+			scriptErrs = append(scriptErrs, err)
+			return nil
+		},
 		ScriptStateBucket: c.scriptStateBucket,
+		ScriptTimeout:     c.ScriptTimeout,
 		Stdout:            c.Stdout,
 		Umask:             ts.Umask,
 		Verbose:           c.Verbose,
 	}
+	var scriptLog *lazyFile
+	if c.ScriptLogs > 0 {
+		scriptLogsDir := filepath.Join(filepath.Dir(c.getPersistentStateFile()), scriptLogsDirName)
+		scriptLog = &lazyFile{
+			fs:   c.fs,
+			path: filepath.Join(scriptLogsDir, "scripts-"+time.Now().Format("20060102T150405")+".log"),
+			perm: 0o600,
+		}
+		applyOptions.ScriptLog = scriptLog
+		defer func() {
+			scriptLog.Close()
+			if scriptLog.created {
+				_ = c.pruneScriptLogs(scriptLogsDir)
+			}
+		}()
+	}
+
+	mutator := chezmoi.NewChangeRecordingMutator(c.mutator, ts.DestDir)
+	var appliedEntries []chezmoi.Entry
 	if len(args) == 0 {
-		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
+		if err := ts.Apply(fs, mutator, c.Follow, applyOptions); err != nil {
+			return err
+		}
+		appliedEntries = ts.AllEntries()
+	} else {
+		entries, err := c.getEntries(ts, args)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := entry.Apply(fs, mutator, c.Follow, applyOptions); err != nil {
+				return err
+			}
+			appliedEntries = entry.AppendAllEntries(appliedEntries)
+		}
+	}
+	if !readOnly && !c.DryRun {
+		if err := c.recordEntryStates(persistentState, ts, appliedEntries); err != nil {
+			return err
+		}
 	}
-	entries, err := c.getEntries(ts, args)
+
+	for _, trigger := range ts.Triggered(mutator.Changed()) {
+		if c.triggerOutput != nil {
+			fmt.Fprintf(c.triggerOutput, "trigger: %s\n", trigger.Name)
+		}
+		if c.noScripts || readOnly {
+			continue
+		}
+		if err := trigger.Apply(applyOptions); err != nil {
+			return err
+		}
+	}
+
+	if len(scriptErrs) == 0 {
+		return nil
+	}
+	fmt.Fprintf(c.Stderr, "%d script(s) failed:\n", len(scriptErrs))
+	for _, err := range scriptErrs {
+		fmt.Fprintf(c.Stderr, "  %v\n", err)
+	}
+	if scriptLog != nil && scriptLog.created {
+		fmt.Fprintf(c.Stderr, "Script output was logged to %s\n", scriptLog.path)
+	}
+	return errExitFailure
+}
+
+// pruneScriptLogs removes all but the newest c.ScriptLogs script logs in dir.
+func (c *Config) pruneScriptLogs(dir string) error {
+	infos, err := c.fs.ReadDir(dir)
 	if err != nil {
 		return err
 	}
-	for _, entry := range entries {
-		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
+	var names []string
+	for _, info := range infos {
+		if name := info.Name(); info.Mode().IsRegular() && strings.HasPrefix(name, "scripts-") && strings.HasSuffix(name, ".log") {
+			names = append(names, name)
+		}
+	}
+	// Log file names contain their timestamp, so sorting them sorts them by
+	// age.
+	sort.Strings(names)
+	for len(names) > c.ScriptLogs {
+		if err := c.fs.Remove(filepath.Join(dir, names[0])); err != nil {
 			return err
 		}
+		names = names[1:]
 	}
 	return nil
 }
 
-func (c *Config) autoCommit(vcs VCS) error {
+// autoCommit commits all changes in the source directory with vcs. command
+// and args are the chezmoi command and arguments that made the changes.
+func (c *Config) autoCommit(vcs VCS, command string, args []string) error {
+	if builtinVCS, ok := vcs.(builtinGitVCS); ok {
+		return c.builtinAutoCommit(builtinVCS, command, args)
+	}
 	addArgs := vcs.AddArgs(".")
 	if addArgs == nil {
 		return fmt.Errorf("%s: autocommit not supported", c.SourceVCS.Command)
@@ -214,27 +412,27 @@ func (c *Config) autoCommit(vcs VCS) error {
 	if err := c.run(c.SourceDir, c.SourceVCS.Command, addArgs...); err != nil {
 		return err
 	}
-	output, err := c.output(c.SourceDir, c.SourceVCS.Command, vcs.StatusArgs()...)
-	if err != nil {
-		return err
+	statusArgs := vcs.StatusArgs()
+	if statusArgs == nil {
+		return fmt.Errorf("%s: autocommit not supported", c.SourceVCS.Command)
 	}
-	status, err := vcs.ParseStatusOutput(output)
+	output, err := c.output(c.SourceDir, c.SourceVCS.Command, statusArgs...)
 	if err != nil {
 		return err
 	}
-	commitMessageText, err := getAsset(commitMessageTemplateAsset)
-	if err != nil {
-		return err
+	if len(bytes.TrimSpace(output)) == 0 {
+		// There is nothing to commit.
+		return nil
 	}
-	commitMessageTmpl, err := template.New("commit_message").Funcs(c.templateFuncs).Parse(string(commitMessageText))
+	status, err := vcs.ParseStatusOutput(output)
 	if err != nil {
 		return err
 	}
-	sb := &strings.Builder{}
-	if err := commitMessageTmpl.Execute(sb, status); err != nil {
+	commitMessage, err := c.getCommitMessage(status, command, args)
+	if err != nil {
 		return err
 	}
-	commitArgs := vcs.CommitArgs(sb.String())
+	commitArgs := vcs.CommitArgs(commitMessage)
 	return c.run(c.SourceDir, c.SourceVCS.Command, commitArgs...)
 }
 
@@ -247,7 +445,12 @@ func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error
 		return nil
 	}
 	if c.SourceVCS.AutoCommit || c.SourceVCS.AutoPush {
-		if err := c.autoCommit(vcs); err != nil {
+		targets := args
+		// The first argument to chattr is the attributes, not a target.
+		if cmd.Name() == "chattr" && len(targets) > 0 {
+			targets = targets[1:]
+		}
+		if err := c.autoCommit(vcs, cmd.Name(), targets); err != nil {
 			return err
 		}
 	}
@@ -260,6 +463,15 @@ func (c *Config) autoCommitAndAutoPush(cmd *cobra.Command, args []string) error
 }
 
 func (c *Config) autoPush(vcs VCS) error {
+	if builtinVCS, ok := vcs.(builtinGitVCS); ok {
+		rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+		if err != nil {
+			return err
+		}
+		return c.runBuiltinVCS(func() error {
+			return builtinVCS.Push(rawSourceDir)
+		}, "push")
+	}
 	pushArgs := vcs.PushArgs()
 	if pushArgs == nil {
 		return fmt.Errorf("%s: autopush not supported", c.SourceVCS.Command)
@@ -312,6 +524,67 @@ func (c *Config) getData() (map[string]interface{}, error) {
 	for key, value := range c.Data {
 		data[key] = value
 	}
+
+	if c.dataFile != "" {
+		fileData, err := c.readDataFile(c.dataFile)
+		if err != nil {
+			return nil, err
+		}
+		data = mergeData(data, fileData)
+	}
+
+	// Values are strings unless given as key:=json.
+	for _, keyValue := range c.overrideData {
+		components := strings.SplitN(keyValue, "=", 2)
+		key := strings.TrimSuffix(components[0], ":")
+		if len(components) != 2 || key == "" {
+			return nil, fmt.Errorf("%s: invalid override data, expected key=value or key:=json", keyValue)
+		}
+		var value interface{} = components[1]
+		if key != components[0] {
+			if err := json.Unmarshal([]byte(components[1]), &value); err != nil {
+				return nil, fmt.Errorf("%s: %w", keyValue, err)
+			}
+		}
+		data = mergeData(data, nestedData(strings.Split(key, "."), value))
+	}
+
+	return data, nil
+}
+
+// readDataFile reads template data from path. The format is determined by
+// path's extension.
+func (c *Config) readDataFile(path string) (map[string]interface{}, error) {
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "json":
+		err = json.Unmarshal(contents, &data)
+	case "toml":
+		var tree *toml.Tree
+		if tree, err = toml.LoadBytes(contents); err == nil {
+			data = tree.ToMap()
+		}
+	case "yaml", "yml":
+		var yamlData interface{}
+		if err = yaml.Unmarshal(contents, &yamlData); err == nil {
+			var ok bool
+			if data, ok = stringKeyedData(yamlData).(map[string]interface{}); !ok {
+				err = errors.New("not a map")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported data file format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateKeys(data, identifierRegexp); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	return data, nil
 }
 
@@ -407,6 +680,24 @@ func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.E
 	return entries, nil
 }
 
+// getEntryFilter returns the entry filter that includes the entry types in
+// include, excluding those set by --exclude and targets not matching --glob.
+func (c *Config) getEntryFilter(include []string) (*chezmoi.EntryFilter, error) {
+	includeEntryTypes := chezmoi.EntryTypesAll
+	if include != nil {
+		var err error
+		includeEntryTypes, err = chezmoi.ParseEntryTypeSet(include)
+		if err != nil {
+			return nil, err
+		}
+	}
+	excludeEntryTypes, err := chezmoi.ParseEntryTypeSet(c.entryExclude)
+	if err != nil {
+		return nil, err
+	}
+	return chezmoi.NewEntryFilter(includeEntryTypes, excludeEntryTypes, c.entryGlobs)
+}
+
 func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
 	persistentStateFile := c.getPersistentStateFile()
 	if c.DryRun {
@@ -431,9 +722,94 @@ func (c *Config) getPersistentStateFile() string {
 	return filepath.Join(filepath.Dir(getDefaultConfigFile(c.bds)), "chezmoistate.boltdb")
 }
 
+// getScriptEnv returns the extra environment variables for scripts and shells
+// run by chezmoi, and a function to remove any temporary files.
+func (c *Config) getScriptEnv() ([]string, func(), error) {
+	cleanup := func() {}
+
+	sourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	destDir := c.DestDir
+	if destDir != "" {
+		destDir, err = filepath.Abs(destDir)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		destDir, err = c.fs.RawPath(destDir)
+		if err != nil {
+			return nil, cleanup, err
+		}
+	}
+
+	// Set user-defined variables first so that they cannot override chezmoi's
+	// variables. Configuration keys are case insensitive, so variable names
+	// are always upper case.
+	var env []string
+	names := make([]string, 0, len(c.ScriptEnv))
+	for name := range c.ScriptEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, strings.ToUpper(name)+"="+c.ScriptEnv[name])
+	}
+	env = append(env,
+		"CHEZMOI=1",
+		"CHEZMOI_ARCH="+runtime.GOARCH,
+		"CHEZMOI_CONFIG_FILE="+c.configFile,
+		"CHEZMOI_DEST_DIR="+destDir,
+		"CHEZMOI_OS="+runtime.GOOS,
+		"CHEZMOI_SOURCE_DIR="+sourceDir,
+	)
+	if executable, err := os.Executable(); err == nil {
+		env = append(env, "CHEZMOI_EXECUTABLE="+executable)
+	}
+
+	// Scripts are not run in dry run mode, so do not write the data file.
+	if c.ScriptDataFile && !c.DryRun {
+		data, err := c.getData()
+		if err != nil {
+			return nil, cleanup, err
+		}
+		dataJSON, err := json.Marshal(data)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		f, err := ioutil.TempFile("", "chezmoi-data-*.json")
+		if err != nil {
+			return nil, cleanup, err
+		}
+		cleanup = func() {
+			_ = os.Remove(f.Name())
+		}
+		if _, err := f.Write(dataJSON); err != nil {
+			_ = f.Close()
+			cleanup()
+			return nil, func() {}, err
+		}
+		if err := f.Close(); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		env = append(env, "CHEZMOI_DATA_FILE="+f.Name())
+	}
+
+	return env, cleanup, nil
+}
+
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
-	fs := vfs.NewReadOnlyFS(c.fs)
+	fs, err := c.getSourceFS()
+	if err != nil {
+		return nil, err
+	}
+	return c.getTargetStateFromFS(fs, populateOptions)
+}
 
+// getTargetStateFromFS returns the target state populated from the source
+// directory in fs.
+func (c *Config) getTargetStateFromFS(fs vfs.FS, populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
 	data, err := c.getData()
 	if err != nil {
 		return nil, err
@@ -452,12 +828,19 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		c.GPG.Recipient = c.GPGRecipient
 	}
 
+	entryFilter, err := c.getEntryFilter(c.entryInclude)
+	if err != nil {
+		return nil, err
+	}
+
 	ts := chezmoi.NewTargetState(
 		chezmoi.WithDestDir(destDir),
+		chezmoi.WithEntryFilter(entryFilter),
 		chezmoi.WithGPG(&c.GPG),
+		chezmoi.WithInterpreters(c.Interpreters),
 		chezmoi.WithSourceDir(c.SourceDir),
 		chezmoi.WithTemplateData(data),
-		chezmoi.WithTemplateFuncs(c.templateFuncs),
+		chezmoi.WithTemplateFuncs(c.getTemplateFuncs()),
 		chezmoi.WithTemplateOptions(c.Template.Options),
 		chezmoi.WithUmask(os.FileMode(c.Umask)),
 	)
@@ -470,8 +853,37 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 	return ts, nil
 }
 
+// getTemplateFuncs returns the template functions. Functions that run commands
+// are disabled if --no-scripts is set or if the source state is not trusted.
+func (c *Config) getTemplateFuncs() template.FuncMap {
+	var reason string
+	switch {
+	case c.noScripts:
+		reason = "disabled by --no-scripts"
+	case !c.isSourceApproved():
+		reason = "disabled because the source state is not trusted"
+	default:
+		return c.templateFuncs
+	}
+	templateFuncs := make(template.FuncMap, len(c.templateFuncs))
+	for key, value := range c.templateFuncs {
+		templateFuncs[key] = value
+	}
+	for _, key := range c.execTemplateFuncNames {
+		key := key
+		templateFuncs[key] = func(...interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("%s: %s", key, reason)
+		}
+	}
+	return templateFuncs
+}
+
 func (c *Config) getVCS() (VCS, error) {
-	vcs, ok := vcses[filepath.Base(c.SourceVCS.Command)]
+	name := trimExecutableSuffix(filepath.Base(c.SourceVCS.Command))
+	if backend, ok := c.SourceVCS.Backends[strings.ToLower(name)]; ok {
+		return newConfigVCS(name, backend)
+	}
+	vcs, ok := vcses[name]
 	if !ok {
 		return nil, fmt.Errorf("%s: unsupported source VCS command", c.SourceVCS.Command)
 	}
@@ -492,13 +904,16 @@ func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
 
 //nolint:unparam
 func (c *Config) prompt(s, choices string) (byte, error) {
-	r := bufio.NewReader(c.Stdin)
+	// Share the reader with other prompts so that buffered input is not lost.
+	if c.stdinReader == nil {
+		c.stdinReader = bufio.NewReader(c.Stdin)
+	}
 	for {
 		_, err := fmt.Printf("%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
 		if err != nil {
 			return 0, err
 		}
-		line, err := r.ReadString('\n')
+		line, err := c.stdinReader.ReadString('\n')
 		if err != nil {
 			return 0, err
 		}
@@ -511,7 +926,15 @@ func (c *Config) prompt(s, choices string) (byte, error) {
 
 // run runs name argv... in dir.
 func (c *Config) run(dir, name string, argv ...string) error {
+	return c.runWithEnv(dir, nil, name, argv...)
+}
+
+// runWithEnv runs name with argv in dir with env added to the environment.
+func (c *Config) runWithEnv(dir string, env []string, name string, argv ...string) error {
 	cmd := exec.Command(name, argv...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	if dir != "" {
 		var err error
 		cmd.Dir, err = c.fs.RawPath(dir)
@@ -574,12 +997,70 @@ func isWellKnownAbbreviation(word string) bool {
 	return ok
 }
 
+// mergeData returns a new map containing the values of src recursively merged
+// over dst. Neither dst nor src are modified.
+func mergeData(dst, src map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(dst))
+	for key, value := range dst {
+		result[key] = value
+	}
+	for key, srcValue := range src {
+		srcMap, srcOK := srcValue.(map[string]interface{})
+		dstMap, dstOK := result[key].(map[string]interface{})
+		if srcOK && dstOK {
+			result[key] = mergeData(dstMap, srcMap)
+		} else if dstStringMap, ok := result[key].(map[string]string); srcOK && ok {
+			dstMap := make(map[string]interface{}, len(dstStringMap))
+			for k, v := range dstStringMap {
+				dstMap[k] = v
+			}
+			result[key] = mergeData(dstMap, srcMap)
+		} else {
+			result[key] = srcValue
+		}
+	}
+	return result
+}
+
+// nestedData returns a map with value at the path given by keys.
+func nestedData(keys []string, value interface{}) map[string]interface{} {
+	if len(keys) == 1 {
+		return map[string]interface{}{
+			keys[0]: value,
+		}
+	}
+	return map[string]interface{}{
+		keys[0]: nestedData(keys[1:], value),
+	}
+}
+
 func panicOnError(err error) {
 	if err != nil {
 		panic(err)
 	}
 }
 
+// stringKeyedData converts all map[interface{}]interface{}s in data, as
+// returned by gopkg.in/yaml.v2, to map[string]interface{}s.
+func stringKeyedData(data interface{}) interface{} {
+	switch data := data.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(data))
+		for key, value := range data {
+			result[fmt.Sprint(key)] = stringKeyedData(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(data))
+		for i, value := range data {
+			result[i] = stringKeyedData(value)
+		}
+		return result
+	default:
+		return data
+	}
+}
+
 // titilize returns s, titilized.
 func titilize(s string) string {
 	if s == "" {
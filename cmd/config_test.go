@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"text/template"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
 	xdg "github.com/twpayne/go-xdg/v3"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -69,6 +72,124 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 	}
 }
 
+func TestGetDataOverrides(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/ci.yaml": strings.Join([]string{
+			`chezmoi:`,
+			`  hostname: ci-runner`,
+			`  osRelease:`,
+			`    id: alpine`,
+			`email: ci@company.com`,
+			`work:`,
+			`  team: infra`,
+		}, "\n"),
+		"/home/user/laptop.json": `{"chezmoi":{"os":"darwin"}}`,
+		"/home/user/vm.toml":     "[chezmoi]\n  os = \"freebsd\"\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name         string
+		dataFile     string
+		overrideData []string
+		check        func(*testing.T, map[string]interface{})
+		wantErr      bool
+	}{
+		{
+			name:     "yaml_data_file",
+			dataFile: "/home/user/ci.yaml",
+			check: func(t *testing.T, data map[string]interface{}) {
+				chezmoiData := data["chezmoi"].(map[string]interface{})
+				assert.Equal(t, "ci-runner", chezmoiData["hostname"])
+				assert.Equal(t, "alpine", chezmoiData["osRelease"].(map[string]interface{})["id"])
+				assert.Equal(t, runtime.GOOS, chezmoiData["os"])
+				assert.Equal(t, "ci@company.com", data["email"])
+				assert.Equal(t, map[string]interface{}{
+					"team":  "infra",
+					"level": 1,
+				}, data["work"])
+			},
+		},
+		{
+			name:     "json_data_file",
+			dataFile: "/home/user/laptop.json",
+			check: func(t *testing.T, data map[string]interface{}) {
+				assert.Equal(t, "darwin", data["chezmoi"].(map[string]interface{})["os"])
+				assert.Equal(t, "user@home.org", data["email"])
+			},
+		},
+		{
+			name:     "toml_data_file",
+			dataFile: "/home/user/vm.toml",
+			check: func(t *testing.T, data map[string]interface{}) {
+				assert.Equal(t, "freebsd", data["chezmoi"].(map[string]interface{})["os"])
+			},
+		},
+		{
+			name:         "override_data",
+			dataFile:     "/home/user/ci.yaml",
+			overrideData: []string{"chezmoi.os=windows", "email=dev@company.com", "personal=false", "version=1.10", "work.level=2", "quoted=a: b"},
+			check: func(t *testing.T, data map[string]interface{}) {
+				chezmoiData := data["chezmoi"].(map[string]interface{})
+				assert.Equal(t, "windows", chezmoiData["os"])
+				assert.Equal(t, "ci-runner", chezmoiData["hostname"])
+				assert.Equal(t, "dev@company.com", data["email"])
+				assert.Equal(t, "false", data["personal"])
+				assert.Equal(t, "1.10", data["version"])
+				assert.Equal(t, "a: b", data["quoted"])
+				assert.Equal(t, map[string]interface{}{
+					"team":  "infra",
+					"level": "2",
+				}, data["work"])
+			},
+		},
+		{
+			name:         "override_data_json",
+			overrideData: []string{"personal:=false", "work.level:=2", "work.tags:=[\"a\", \"b\"]", "email:=\"dev@company.com\""},
+			check: func(t *testing.T, data map[string]interface{}) {
+				assert.Equal(t, false, data["personal"])
+				assert.Equal(t, "dev@company.com", data["email"])
+				assert.Equal(t, map[string]interface{}{
+					"level": 2.0,
+					"tags":  []interface{}{"a", "b"},
+				}, data["work"])
+			},
+		},
+		{
+			name:         "invalid_override_data_json",
+			overrideData: []string{"personal:=no"},
+			wantErr:      true,
+		},
+		{
+			name:         "invalid_override_data",
+			overrideData: []string{"email"},
+			wantErr:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestConfig(fs, withData(map[string]interface{}{
+				"email": "user@home.org",
+				"work": map[string]interface{}{
+					"level": 1,
+				},
+			}))
+			c.dataFile = tc.dataFile
+			c.overrideData = tc.overrideData
+			data, err := c.getData()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, data)
+			assert.Equal(t, map[string]interface{}{
+				"level": 1,
+			}, c.Data["work"])
+		})
+	}
+}
+
 func TestUpperSnakeCaseToCamelCase(t *testing.T) {
 	for s, want := range map[string]string{
 		"BUG_REPORT_URL":   "bugReportURL",
@@ -180,6 +301,12 @@ func withGenericSecretCmdConfig(genericSecretCmdConfig genericSecretCmdConfig) c
 	}
 }
 
+func withInitCmdConfig(initCmdConfig initCmdConfig) configOption {
+	return func(c *Config) {
+		c.init = initCmdConfig
+	}
+}
+
 func withMutator(mutator chezmoi.Mutator) configOption {
 	return func(c *Config) {
 		c.mutator = mutator
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// A configVCSConfig is the definition of a VCS in the config file. Each
+// argument is a template executed with the data in a configVCSTemplateData.
+type configVCSConfig struct {
+	Add           []string
+	Clone         []string
+	Commit        []string
+	Init          []string
+	Pull          []string
+	Push          []string
+	Status        []string
+	StatusFormat  string
+	Version       []string
+	VersionRegexp string
+}
+
+// A configVCSTemplateData is the data passed to a configVCS's argument
+// templates.
+type configVCSTemplateData struct {
+	Dir     string
+	Message string
+	Path    string
+	Repo    string
+}
+
+// A configVCS is a VCS defined in the config file.
+type configVCS struct {
+	add           []*template.Template
+	clone         []*template.Template
+	commit        []*template.Template
+	init          []*template.Template
+	pull          []*template.Template
+	push          []*template.Template
+	status        []*template.Template
+	statusFormat  string
+	version       []*template.Template
+	versionRegexp *regexp.Regexp
+}
+
+// sampleConfigVCSTemplateData is used to check that a configVCS's argument
+// templates execute before they are needed.
+var sampleConfigVCSTemplateData = configVCSTemplateData{
+	Dir:     "dir",
+	Message: "message",
+	Path:    "path",
+	Repo:    "repo",
+}
+
+// newConfigVCS returns a new configVCS from the definition in c. It returns an
+// error if any argument template fails to parse or to execute.
+func newConfigVCS(name string, c configVCSConfig) (*configVCS, error) {
+	v := &configVCS{
+		statusFormat: c.StatusFormat,
+	}
+	for _, field := range []struct {
+		key   string
+		args  []string
+		tmpls *[]*template.Template
+	}{
+		{key: "add", args: c.Add, tmpls: &v.add},
+		{key: "clone", args: c.Clone, tmpls: &v.clone},
+		{key: "commit", args: c.Commit, tmpls: &v.commit},
+		{key: "init", args: c.Init, tmpls: &v.init},
+		{key: "pull", args: c.Pull, tmpls: &v.pull},
+		{key: "push", args: c.Push, tmpls: &v.push},
+		{key: "status", args: c.Status, tmpls: &v.status},
+		{key: "version", args: c.Version, tmpls: &v.version},
+	} {
+		for i, arg := range field.args {
+			tmpl, err := template.New(fmt.Sprintf("%s[%d]", field.key, i)).Option("missingkey=error").Parse(arg)
+			if err != nil {
+				return nil, fmt.Errorf("sourceVCS.backends.%s.%s: %w", name, field.key, err)
+			}
+			if err := tmpl.Execute(&strings.Builder{}, sampleConfigVCSTemplateData); err != nil {
+				return nil, fmt.Errorf("sourceVCS.backends.%s.%s: %w", name, field.key, err)
+			}
+			*field.tmpls = append(*field.tmpls, tmpl)
+		}
+	}
+	switch c.StatusFormat {
+	case "git", "hg":
+	case "":
+		if c.Status != nil {
+			return nil, fmt.Errorf("sourceVCS.backends.%s.statusFormat: must be git or hg", name)
+		}
+	default:
+		return nil, fmt.Errorf("sourceVCS.backends.%s.statusFormat: %s: must be git or hg", name, c.StatusFormat)
+	}
+	if c.VersionRegexp != "" {
+		var err error
+		v.versionRegexp, err = regexp.Compile(c.VersionRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("sourceVCS.backends.%s.versionRegexp: %w", name, err)
+		}
+	}
+	return v, nil
+}
+
+func (v *configVCS) AddArgs(path string) []string {
+	return executeArgTemplates(v.add, configVCSTemplateData{Path: path})
+}
+
+func (v *configVCS) CloneArgs(repo, dir string) []string {
+	return executeArgTemplates(v.clone, configVCSTemplateData{Dir: dir, Repo: repo})
+}
+
+func (v *configVCS) CommitArgs(message string) []string {
+	return executeArgTemplates(v.commit, configVCSTemplateData{Message: message})
+}
+
+func (v *configVCS) InitArgs() []string {
+	return executeArgTemplates(v.init, configVCSTemplateData{})
+}
+
+func (v *configVCS) ParseStatusOutput(output []byte) (interface{}, error) {
+	switch v.statusFormat {
+	case "git":
+		return git.ParseStatusPorcelainV2(output)
+	case "hg":
+		return git.ParseHgStatus(output)
+	default:
+		return nil, nil
+	}
+}
+
+func (v *configVCS) PullArgs() []string {
+	return executeArgTemplates(v.pull, configVCSTemplateData{})
+}
+
+func (v *configVCS) PushArgs() []string {
+	return executeArgTemplates(v.push, configVCSTemplateData{})
+}
+
+func (v *configVCS) StatusArgs() []string {
+	return executeArgTemplates(v.status, configVCSTemplateData{})
+}
+
+func (v *configVCS) UpdateSubmodulesArgs() []string {
+	return nil
+}
+
+func (v *configVCS) VersionArgs() []string {
+	return executeArgTemplates(v.version, configVCSTemplateData{})
+}
+
+func (v *configVCS) VersionRegexp() *regexp.Regexp {
+	return v.versionRegexp
+}
+
+// executeArgTemplates returns the result of executing tmpls with data. It
+// returns nil if tmpls is empty, which callers treat as the operation not being
+// supported. newConfigVCS checks that every template executes, so execution can
+// only fail here for values of data that the template itself rejects, for
+// example by indexing past the end of a string.
+func executeArgTemplates(tmpls []*template.Template, data configVCSTemplateData) []string {
+	if len(tmpls) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(tmpls))
+	for _, tmpl := range tmpls {
+		sb := &strings.Builder{}
+		if err := tmpl.Execute(sb, data); err != nil {
+			return nil
+		}
+		args = append(args, sb.String())
+	}
+	return args
+}
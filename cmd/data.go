@@ -26,6 +26,7 @@ func init() {
 
 	persistentFlags := dataCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.data.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
+	addOverrideDataFlags(dataCmd)
 }
 
 func (c *Config) runDataCmd(cmd *cobra.Command, args []string) error {
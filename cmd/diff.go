@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"unicode"
 
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
@@ -18,9 +22,20 @@ import (
 )
 
 type diffCmdConfig struct {
-	Format  string
-	NoPager bool
-	Pager   string
+	Args         []string
+	Command      string
+	CommandPager bool
+	DirDiff      bool
+	Format       string
+	NoPager      bool
+	Pager        string
+	between      bool
+}
+
+// diffCommandTemplateData is the data passed to diff.args templates.
+type diffCommandTemplateData struct {
+	Destination string
+	Target      string
 }
 
 var diffCmd = &cobra.Command{
@@ -38,17 +53,38 @@ func init() {
 	persistentFlags := diffCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.Diff.Format, "format", "f", config.Diff.Format, "format, \"chezmoi\" or \"git\"")
 	persistentFlags.BoolVar(&config.Diff.NoPager, "no-pager", false, "disable pager")
+	persistentFlags.BoolVar(&config.Diff.between, "between", false, "print the diff between the target states of two revisions")
+	persistentFlags.BoolVar(&config.Diff.DirDiff, "dir-diff", config.Diff.DirDiff, "run the diff command once on directories containing all changes")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(diffCmd, 1)
+	addSourceRefFlag(diffCmd)
+	addEntryFilterFlags(diffCmd)
 }
 
 func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	c.DryRun = true // Prevent scripts from running.
 
-	switch c.Diff.Format {
-	case "chezmoi":
+	if c.Diff.between {
+		if len(args) != 2 {
+			return errors.New("--between requires exactly two revisions")
+		}
+		if c.sourceRef != "" {
+			return errors.New("--between and --source-ref cannot be used together")
+		}
+	}
+
+	var argTmpls []*template.Template
+	switch {
+	case c.Diff.Command != "":
+		c.mutator = chezmoi.NullMutator{}
+		var err error
+		argTmpls, err = parseDiffArgs(c.Diff.Args)
+		if err != nil {
+			return err
+		}
+	case c.Diff.Format == "chezmoi":
 		c.mutator = chezmoi.NullMutator{}
-	case "git":
+	case c.Diff.Format == "git":
 		c.mutator = chezmoi.NewFSMutator(vfs.NewReadOnlyFS(c.fs))
 	default:
 		return fmt.Errorf("unknown diff format: %q", c.Diff.Format)
@@ -65,22 +101,180 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	if c.Diff.NoPager || c.Diff.Pager == "" {
-		switch c.Diff.Format {
-		case "chezmoi":
-			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
-		case "git":
-			unifiedEncoder := diff.NewUnifiedEncoder(c.Stdout, diff.DefaultContextLines)
+	// diff.command might be interactive, like vimdiff, and need the terminal,
+	// so its output is only piped into the pager if diff.commandPager is set.
+	runWithPager := c.runWithPager
+	if c.Diff.Command != "" && !c.Diff.between && !c.Diff.CommandPager {
+		runWithPager = func(f func(io.Writer) error) error {
+			return f(c.Stdout)
+		}
+	}
+
+	return runWithPager(func(w io.Writer) error {
+		if c.Diff.between {
+			return c.diffSourceRevisions(w, persistentState, args[0], args[1])
+		}
+		var contentsRecordingMutator *chezmoi.ContentsRecordingMutator
+		switch {
+		case c.Diff.Command != "":
+			contentsRecordingMutator = chezmoi.NewContentsRecordingMutator(c.mutator, c.fs, c.DestDir)
+			c.mutator = contentsRecordingMutator
+		case c.Diff.Format == "chezmoi":
+			c.mutator = chezmoi.NewVerboseMutator(w, c.mutator, c.colored, c.maxDiffDataSize)
+		case c.Diff.Format == "git":
+			unifiedEncoder := diff.NewUnifiedEncoder(w, diff.DefaultContextLines)
 			if c.colored {
 				unifiedEncoder.SetColor(diff.NewColorConfig())
 			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.fs, c.DestDir+string(filepath.Separator))
+		}
+		c.triggerOutput = w
+		if err := c.applyArgs(args, persistentState, true); err != nil {
+			return err
+		}
+		if contentsRecordingMutator == nil {
+			return nil
+		}
+		return c.runDiffCommand(w, argTmpls, contentsRecordingMutator.Changes())
+	})
+}
+
+// runDiffCommand runs the diff command on changes, writing its output to w.
+// The contents of both sides of each change are written to temporary files.
+func (c *Config) runDiffCommand(w io.Writer, argTmpls []*template.Template, changes []*chezmoi.ContentsChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tempDir, err := ioutil.TempDir("", "chezmoi-diff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	destinationDir := filepath.Join(tempDir, "destination")
+	targetDir := filepath.Join(tempDir, "target")
+
+	for _, change := range changes {
+		destinationPath, err := writeDiffFile(destinationDir, change.Name, change.Destination, change.DestinationExists)
+		if err != nil {
+			return err
+		}
+		targetPath, err := writeDiffFile(targetDir, change.Name, change.Target, change.TargetExists)
+		if err != nil {
+			return err
+		}
+		if c.Diff.DirDiff {
+			continue
+		}
+		if err := c.execDiffCommand(w, argTmpls, destinationPath, targetPath); err != nil {
+			return fmt.Errorf("%s: %w", change.Name, err)
+		}
+	}
+
+	if !c.Diff.DirDiff {
+		return nil
+	}
+	for _, dir := range []string{destinationDir, targetDir} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	return c.execDiffCommand(w, argTmpls, destinationDir, targetDir)
+}
+
+// execDiffCommand runs the diff command with the arguments from argTmpls,
+// writing its output to w. Like git difftool, it ignores the command's exit
+// status, as many diff tools exit with a non-zero status when the files
+// differ.
+func (c *Config) execDiffCommand(w io.Writer, argTmpls []*template.Template, destination, target string) error {
+	data := diffCommandTemplateData{
+		Destination: destination,
+		Target:      target,
+	}
+	args := make([]string, 0, len(argTmpls))
+	for _, argTmpl := range argTmpls {
+		sb := &strings.Builder{}
+		if err := argTmpl.Execute(sb, data); err != nil {
+			return err
+		}
+		args = append(args, sb.String())
+	}
+	//nolint:gosec
+	cmd := exec.Command(c.Diff.Command, args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = w
+	cmd.Stderr = c.Stderr
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+	return nil
+}
+
+// parseDiffArgs parses the diff.args templates. If args is empty, the
+// destination and target are passed as the only arguments.
+func parseDiffArgs(args []string) ([]*template.Template, error) {
+	if len(args) == 0 {
+		args = []string{"{{ .Destination }}", "{{ .Target }}"}
+	}
+	argTmpls := make([]*template.Template, 0, len(args))
+	for i, arg := range args {
+		argTmpl, err := template.New(fmt.Sprintf("diff.args[%d]", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, err
 		}
-		return c.applyArgs(args, persistentState)
+		argTmpls = append(argTmpls, argTmpl)
+	}
+	return argTmpls, nil
+}
+
+// writeDiffFile writes contents to name in dir and returns its path, or
+// returns os.DevNull if exists is false.
+func writeDiffFile(dir, name string, contents []byte, exists bool) (string, error) {
+	if !exists {
+		return os.DevNull, nil
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, contents, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// diffSourceRevisions writes the difference between the target states of
+// revisions rev1 and rev2 of the source directory to w.
+func (c *Config) diffSourceRevisions(w io.Writer, persistentState chezmoi.PersistentState, rev1, rev2 string) error {
+	var revSnapshots [2]map[string]targetSnapshot
+	for i, rev := range []string{rev1, rev2} {
+		c.sourceRef = rev
+		if err := c.ensureSourceTrusted(persistentState, true); err != nil {
+			return err
+		}
+		ts, err := c.getTargetState(nil)
+		if err != nil {
+			return err
+		}
+		revSnapshots[i], err = snapshotTargetState(ts)
+		if err != nil {
+			return err
+		}
+	}
+	c.sourceRef = ""
+	_, err := c.diffTargetSnapshots(w, revSnapshots[0], revSnapshots[1])
+	return err
+}
+
+// runWithPager runs f with the writer to which output should be written,
+// which is the diff pager if one is configured.
+func (c *Config) runWithPager(f func(io.Writer) error) error {
+	if c.Diff.NoPager || c.Diff.Pager == "" {
+		return f(c.Stdout)
 	}
 
 	var pagerCmd *exec.Cmd
-	var pagerStdinPipe io.WriteCloser
 
 	// If the pager command contains any spaces, assume that it is a full
 	// shell command to be executed via the user's shell. Otherwise, execute
@@ -93,7 +287,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		//nolint:gosec
 		pagerCmd = exec.Command(c.Diff.Pager)
 	}
-	pagerStdinPipe, err = pagerCmd.StdinPipe()
+	pagerStdinPipe, err := pagerCmd.StdinPipe()
 	if err != nil {
 		return err
 	}
@@ -103,18 +297,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	switch c.Diff.Format {
-	case "chezmoi":
-		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize)
-	case "git":
-		unifiedEncoder := diff.NewUnifiedEncoder(pagerStdinPipe, diff.DefaultContextLines)
-		if c.colored {
-			unifiedEncoder.SetColor(diff.NewColorConfig())
-		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
-	}
-
-	if err := c.applyArgs(args, persistentState); err != nil {
+	if err := f(pagerStdinPipe); err != nil {
 		return err
 	}
 
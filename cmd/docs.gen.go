@@ -587,6 +587,7 @@ func init() {
 		"* [Use a hosted repo to manage your dotfiles across multiple machines](#use-a-hosted-repo-to-manage-your-dotfiles-across-multiple-machines)\n" +
 		"* [Pull the latest changes from your repo and apply them](#pull-the-latest-changes-from-your-repo-and-apply-them)\n" +
 		"* [Pull the latest changes from your repo and see what would change, without actually applying the changes](#pull-the-latest-changes-from-your-repo-and-see-what-would-change-without-actually-applying-the-changes)\n" +
+		"* [See or apply an earlier version of your dotfiles](#see-or-apply-an-earlier-version-of-your-dotfiles)\n" +
 		"* [Automatically commit and push changes to your repo](#automatically-commit-and-push-changes-to-your-repo)\n" +
 		"* [Use templates to manage files that vary from machine to machine](#use-templates-to-manage-files-that-vary-from-machine-to-machine)\n" +
 		"* [Use completely separate config files on different machines](#use-completely-separate-config-files-on-different-machines)\n" +
@@ -610,8 +611,12 @@ func init() {
 		"* [Use scripts to perform actions](#use-scripts-to-perform-actions)\n" +
 		"  * [Understand how scripts work](#understand-how-scripts-work)\n" +
 		"  * [Install packages with scripts](#install-packages-with-scripts)\n" +
+		"  * [Run scripts with an interpreter](#run-scripts-with-an-interpreter)\n" +
+		"  * [Run commands when files change](#run-commands-when-files-change)\n" +
+		"  * [Handle slow or failing scripts](#handle-slow-or-failing-scripts)\n" +
 		"* [Import archives](#import-archives)\n" +
 		"* [Export archives](#export-archives)\n" +
+		"* [Use chezmoi on a machine without git](#use-chezmoi-on-a-machine-without-git)\n" +
 		"* [Use a non-git version control system](#use-a-non-git-version-control-system)\n" +
 		"* [Customize the `diff` command](#customize-the-diff-command)\n" +
 		"* [Use a merge tool other than vimdiff](#use-a-merge-tool-other-than-vimdiff)\n" +
@@ -654,6 +659,9 @@ func init() {
 		"    chezmoi update\n" +
 		"\n" +
 		"This runs `git pull --rebase` in your source directory and then `chezmoi apply`.\n" +
+		"To see what changed and decide whether to apply it, run:\n" +
+		"\n" +
+		"    chezmoi update --prompt\n" +
 		"\n" +
 		"## Pull the latest changes from your repo and see what would change, without actually applying the changes\n" +
 		"\n" +
@@ -671,6 +679,25 @@ func init() {
 		"\n" +
 		"to apply them.\n" +
 		"\n" +
+		"## See or apply an earlier version of your dotfiles\n" +
+		"\n" +
+		"`chezmoi apply`, `archive`, `cat`, `diff`, and `dump` accept `--source-ref` to\n" +
+		"read the source state from a git revision instead of from your source\n" +
+		"directory. For example, to see how your `~/.bashrc` looked one commit ago and\n" +
+		"then roll back to it, without changing your source directory, run:\n" +
+		"\n" +
+		"    chezmoi cat --source-ref HEAD~1 ~/.bashrc\n" +
+		"    chezmoi apply --source-ref HEAD~1 ~/.bashrc\n" +
+		"\n" +
+		"To compare the target states of two revisions, run:\n" +
+		"\n" +
+		"    chezmoi diff --between v1.0.0 HEAD\n" +
+		"\n" +
+		"To see how a single target changed over time, even if its source file was\n" +
+		"renamed, for example by `chezmoi chattr`, run:\n" +
+		"\n" +
+		"    chezmoi history ~/.bashrc\n" +
+		"\n" +
 		"## Automatically commit and push changes to your repo\n" +
 		"\n" +
 		"chezmoi can automatically commit and push changes to your source directory to\n" +
@@ -692,6 +719,31 @@ func init() {
 		"accidentally add a secret in plain text, that secret will be pushed to your\n" +
 		"public repo.\n" +
 		"\n" +
+		"You can customize the generated commit messages, for example to use\n" +
+		"conventional commit prefixes or to review each message in your editor, see the\n" +
+		"[reference manual](REFERENCE.md#commit-messages).\n" +
+		"\n" +
+		"To pull changes, add back files that you have edited, commit, push, and apply in\n" +
+		"a single step, run:\n" +
+		"\n" +
+		"    chezmoi sync\n" +
+		"\n" +
+		"If a file has been edited both locally and in your repo, `chezmoi sync` runs\n" +
+		"your merge command so you can resolve the conflict.\n" +
+		"\n" +
+		"To check whether your source directory has uncommitted changes or is ahead of or\n" +
+		"behind your repo, run:\n" +
+		"\n" +
+		"    chezmoi source-status\n" +
+		"\n" +
+		"`chezmoi source-status --format=json` prints the same information as JSON, for\n" +
+		"example for your shell prompt. To be warned whenever you run `chezmoi apply`\n" +
+		"with a source directory that is not in sync, add the following to your config\n" +
+		"file:\n" +
+		"\n" +
+		"    [sourceVCS]\n" +
+		"        warnOnApply = true\n" +
+		"\n" +
 		"## Use templates to manage files that vary from machine to machine\n" +
 		"\n" +
 		"The primary goal of chezmoi is to manage configuration files across multiple\n" +
@@ -883,6 +935,16 @@ func init() {
 		"\n" +
 		"Then `chezmoi init` will create an initial `chezmoi.toml` using this template.\n" +
 		"`promptString` is a special function that prompts the user (you) for a value.\n" +
+		"`promptBool`, `promptInt`, and `promptChoice` prompt for booleans, integers, and\n" +
+		"one of a list of choices respectively, and all prompt functions accept an\n" +
+		"optional default value. The `prompt*Once` variants reuse the value from your\n" +
+		"existing config file, if present, so you are only asked new questions when you\n" +
+		"run `chezmoi init` again.\n" +
+		"\n" +
+		"For unattended provisioning, pass the answers on the command line and use the\n" +
+		"default values for everything else:\n" +
+		"\n" +
+		"    chezmoi init --promptDefaults --promptString email=john@home.org --promptBool personal=false https://github.com/user/dotfiles.git\n" +
 		"\n" +
 		"To test this template, use `chezmoi execute-template` with the `--init` and\n" +
 		"`--promptString` flags, for example:\n" +
@@ -1283,9 +1345,20 @@ func init() {
 		"sparingly. Any script should be idempotent, even `run_once_` scripts.\n" +
 		"\n" +
 		"Scripts must be created manually in the source directory, typically by running\n" +
-		"`chezmoi cd` and then creating a file with a `run_` prefix. Scripts are executed\n" +
-		"directly using `exec` and must include a shebang line or be executable binaries.\n" +
-		"There is no need to set the executable bit on the script.\n" +
+		"`chezmoi cd` and then creating a file with a `run_` prefix. Unless an\n" +
+		"interpreter is configured for their extension, scripts are executed directly\n" +
+		"using `exec` and must include a shebang line or be executable binaries. There is\n" +
+		"no need to set the executable bit on the script.\n" +
+		"\n" +
+		"The first time that you apply a source state cloned from a remote repository,\n" +
+		"and after every new commit, chezmoi lists its scripts and any template\n" +
+		"functions that run commands and asks you to approve them. To apply a dotfiles\n" +
+		"repo that you do not trust without running anything, use `chezmoi init --apply\n" +
+		"--no-scripts` *repo*.\n" +
+		"\n" +
+		"Scripts are run with environment variables describing chezmoi's\n" +
+		"configuration, for example `CHEZMOI_SOURCE_DIR`. See the [reference\n" +
+		"manual](REFERENCE.md#script-environment-variables) for the full list.\n" +
 		"\n" +
 		"Scripts with the suffix `.tmpl` are treated as templates, with the usual\n" +
 		"template variables available. If, after executing the template, the result is\n" +
@@ -1322,6 +1395,52 @@ func init() {
 		"\n" +
 		"This will install `ripgrep` on both Debian/Ubuntu Linux systems and macOS.\n" +
 		"\n" +
+		"### Run scripts with an interpreter\n" +
+		"\n" +
+		"Instead of relying on a shebang line, you can configure an interpreter for\n" +
+		"scripts by their file extension, without the leading dot, in your config file,\n" +
+		"for example:\n" +
+		"\n" +
+		"    [interpreters.py]\n" +
+		"      command = \"python3\"\n" +
+		"    [interpreters.rb]\n" +
+		"      command = \"ruby\"\n" +
+		"      args = [\"-w\"]\n" +
+		"\n" +
+		"With this configuration, `run_once_setup.py` will be run with `python3\n" +
+		"/path/to/script`. `chezmoi doctor` checks that every configured interpreter\n" +
+		"exists, and `chezmoi dump` shows the interpreter that will be used for each\n" +
+		"script.\n" +
+		"\n" +
+		"### Run commands when files change\n" +
+		"\n" +
+		"To run a command only when some of your dotfiles change, for example to reload\n" +
+		"tmux or rebuild the font cache, list them in a `.chezmoitriggers` file in the\n" +
+		"root of your source directory:\n" +
+		"\n" +
+		"    .tmux.conf          tmux source-file ~/.tmux.conf\n" +
+		"    .local/share/fonts  fc-cache\n" +
+		"\n" +
+		"`chezmoi apply` runs each matching command once, after all your dotfiles have\n" +
+		"been updated. See the [reference\n" +
+		"manual](REFERENCE.md#chezmoitriggers) for details.\n" +
+		"\n" +
+		"### Handle slow or failing scripts\n" +
+		"\n" +
+		"To keep going when a script fails, and get a summary of all failures at the\n" +
+		"end, set `scriptErrors` in your config file:\n" +
+		"\n" +
+		"    scriptErrors = \"continue\"\n" +
+		"\n" +
+		"To stop scripts from hanging `chezmoi apply`, set a timeout for all scripts\n" +
+		"with `scriptTimeout = \"5m\"`, or for a single script with a comment like:\n" +
+		"\n" +
+		"    # chezmoi:script:timeout=30s\n" +
+		"\n" +
+		"To keep the output of the last ten runs in `~/.config/chezmoi/logs`, set\n" +
+		"`scriptLogs = 10`. See the [reference\n" +
+		"manual](REFERENCE.md#script-errors-and-timeouts) for details.\n" +
+		"\n" +
 		"## Import archives\n" +
 		"\n" +
 		"It is occasionally useful to import entire archives of configuration into your\n" +
@@ -1349,6 +1468,30 @@ func init() {
 		"\n" +
 		"which lists all the targets in the target state.\n" +
 		"\n" +
+		"## Use chezmoi on a machine without git\n" +
+		"\n" +
+		"chezmoi includes a builtin implementation of git, so you can manage your\n" +
+		"dotfiles on machines where git is not installed, such as minimal containers. To\n" +
+		"use it, specify:\n" +
+		"\n" +
+		"    [sourceVCS]\n" +
+		"      command = \"builtin\"\n" +
+		"\n" +
+		"The builtin git supports `init`, cloning with `init` (including `--branch` and\n" +
+		"`--depth`), `update`, `sync`, and automatically committing and pushing changes.\n" +
+		"Pulls are fast-forward only. `chezmoi source` is not supported, and\n" +
+		"`update.verifySignatures` requires an external git.\n" +
+		"\n" +
+		"If the machine cannot reach your repo at all, you can instead generate a patch\n" +
+		"on a machine that can, review it, copy it across, and apply it there:\n" +
+		"\n" +
+		"    chezmoi diff --format=git > changes.patch\n" +
+		"    chezmoi apply-patch changes.patch\n" +
+		"\n" +
+		"The changed files must have the same contents on both machines before the\n" +
+		"change, as `chezmoi apply-patch` refuses to apply a patch that does not match\n" +
+		"exactly. Scripts are not included in the patch.\n" +
+		"\n" +
 		"## Use a non-git version control system\n" +
 		"\n" +
 		"By default, chezmoi uses git, but you can use any version control system of your\n" +
@@ -1358,10 +1501,12 @@ func init() {
 		"    [sourceVCS]\n" +
 		"      command = \"hg\"\n" +
 		"\n" +
-		"The source VCS command is used in the chezmoi commands `init`, `source`, and\n" +
-		"`update`, and support for VCSes other than git is limited but easy to add. If\n" +
-		"you'd like to see your VCS better supported, please [open an issue on\n" +
-		"GitHub](https://github.com/twpayne/chezmoi/issues/new/choose).\n" +
+		"Mercurial is supported by all chezmoi commands that use the source VCS,\n" +
+		"including automatically committing and pushing changes.\n" +
+		"\n" +
+		"To use another VCS, define the arguments that chezmoi should pass to it for each\n" +
+		"operation in `sourceVCS.backends`. See the [reference\n" +
+		"manual](REFERENCE.md#source-vcs-backends) for details.\n" +
 		"\n" +
 		"## Customize the `diff` command\n" +
 		"\n" +
@@ -1605,22 +1750,28 @@ func init() {
 		"  * [`--follow`](#--follow)\n" +
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
+		"  * [`--no-scripts`](#--no-scripts)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
 		"* [Configuration file](#configuration-file)\n" +
 		"  * [Configuration variables](#configuration-variables)\n" +
+		"  * [Source VCS backends](#source-vcs-backends)\n" +
+		"  * [Commit messages](#commit-messages)\n" +
 		"* [Source state attributes](#source-state-attributes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
+		"  * [`.chezmoitests`](#chezmoitests)\n" +
+		"  * [`.chezmoitriggers`](#chezmoitriggers)\n" +
 		"  * [`.chezmoiversion`](#chezmoiversion)\n" +
 		"* [Commands](#commands)\n" +
 		"  * [`add` *targets*](#add-targets)\n" +
 		"  * [`apply` [*targets*]](#apply-targets)\n" +
+		"  * [`apply-patch` [*patch*]](#apply-patch-patch)\n" +
 		"  * [`archive`](#archive)\n" +
 		"  * [`cat` targets](#cat-targets)\n" +
 		"  * [`cd`](#cd)\n" +
@@ -1638,8 +1789,10 @@ func init() {
 		"  * [`git` [*arguments*]](#git-arguments)\n" +
 		"  * [`help` *command*](#help-command)\n" +
 		"  * [`hg` [*arguments*]](#hg-arguments)\n" +
+		"  * [`history` *target*](#history-target)\n" +
 		"  * [`init` [*repo*]](#init-repo)\n" +
 		"  * [`import` *filename*](#import-filename)\n" +
+		"  * [`lint`](#lint)\n" +
 		"  * [`manage` *targets*](#manage-targets)\n" +
 		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
@@ -1649,6 +1802,9 @@ func init() {
 		"  * [`secret`](#secret)\n" +
 		"  * [`source` [*args*]](#source-args)\n" +
 		"  * [`source-path` [*targets*]](#source-path-targets)\n" +
+		"  * [`source-status`](#source-status)\n" +
+		"  * [`sync`](#sync)\n" +
+		"  * [`test` [*cases*]](#test-cases)\n" +
 		"  * [`unmanage` *targets*](#unmanage-targets)\n" +
 		"  * [`unmanaged`](#unmanaged)\n" +
 		"  * [`update`](#update)\n" +
@@ -1656,11 +1812,17 @@ func init() {
 		"  * [`verify` [*targets*]](#verify-targets)\n" +
 		"* [Editor configuration](#editor-configuration)\n" +
 		"* [Umask configuration](#umask-configuration)\n" +
+		"* [Script environment variables](#script-environment-variables)\n" +
+		"* [Script errors and timeouts](#script-errors-and-timeouts)\n" +
+		"* [Entry filters](#entry-filters)\n" +
+		"* [Source state trust](#source-state-trust)\n" +
 		"* [Template execution](#template-execution)\n" +
+		"  * [Template directives](#template-directives)\n" +
 		"* [Template variables](#template-variables)\n" +
 		"* [Template functions](#template-functions)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
+		"  * [`includeTemplate` *name* [*data*]](#includetemplate-name-data)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
 		"  * [`keyring` *service* *user*](#keyring-service-user)\n" +
@@ -1669,7 +1831,14 @@ func init() {
 		"  * [`onepassword` *uuid* [*vault-uuid*]](#onepassword-uuid-vault-uuid)\n" +
 		"  * [`onepasswordDocument` *uuid* [*vault-uuid*]](#onepassworddocument-uuid-vault-uuid)\n" +
 		"  * [`pass` *pass-name*](#pass-pass-name)\n" +
-		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
+		"  * [`promptBool` *prompt* [*default*]](#promptbool-prompt-default)\n" +
+		"  * [`promptBoolOnce` *map* *path* *prompt* [*default*]](#promptboolonce-map-path-prompt-default)\n" +
+		"  * [`promptChoice` *prompt* *choices* [*default*]](#promptchoice-prompt-choices-default)\n" +
+		"  * [`promptChoiceOnce` *map* *path* *prompt* *choices* [*default*]](#promptchoiceonce-map-path-prompt-choices-default)\n" +
+		"  * [`promptInt` *prompt* [*default*]](#promptint-prompt-default)\n" +
+		"  * [`promptIntOnce` *map* *path* *prompt* [*default*]](#promptintonce-map-path-prompt-default)\n" +
+		"  * [`promptString` *prompt* [*default*]](#promptstring-prompt-default)\n" +
+		"  * [`promptStringOnce` *map* *path* *prompt* [*default*]](#promptstringonce-map-path-prompt-default)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
 		"  * [`vault` *key*](#vault-key)\n" +
@@ -1737,6 +1906,12 @@ func init() {
 		"\n" +
 		"Print help.\n" +
 		"\n" +
+		"### `--no-scripts`\n" +
+		"\n" +
+		"Do not run scripts or triggers, and make template functions that run commands,\n" +
+		"like `secret` and `pass`, return an error. The source state does not need to be\n" +
+		"[trusted](#source-state-trust).\n" +
+		"\n" +
 		"### `-r`. `--remove`\n" +
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
@@ -1771,40 +1946,138 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Variable                | Type     | Default value             | Description                                         |\n" +
-		"| ----------------------- | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| `bitwarden.command`     | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd.args`               | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
-		"| `cd.command`            | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `color`                 | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"| `data`                  | any      | *none*                    | Template data                                       |\n" +
-		"| `destDir`               | string   | `~`                       | Destination directory                               |\n" +
-		"| `diff.format`           | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"| `diff.pager`            | string   | *none*                    | Pager                                               |\n" +
-		"| `dryRun`                | bool     | `false`                   | Dry run mode                                        |\n" +
-		"| `follow`                | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"| `genericSecret.command` | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass.command`        | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg.command`           | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"| `gpg.recipient`         | string   | *none*                    | GPG recipient                                       |\n" +
-		"| `gpg.symmetric`         | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc.args`        | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"| `keepassxc.command`     | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"| `keepassxc.database`    | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass.command`      | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge.args`            | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"| `merge.command`         | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword.command`   | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass.command`          | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `remove`                | bool     | `false`                   | Remove targets                                      |\n" +
-		"| `sourceDir`             | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"| `sourceVCS.autoCommit`  | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"| `sourceVCS.autoPush`    | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"| `sourceVCS.command`     | string   | `git`                     | Source version control system                       |\n" +
-		"| `template.options`      | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `umask`                 | int      | *from system*             | Umask                                               |\n" +
-		"| `vault.command`         | string   | `vault`                   | Vault CLI command                                   |\n" +
-		"| `verbose`               | bool     | `false`                   | Verbose mode                                        |\n" +
+		"| Variable                              | Type     | Default value            | Description                                          |\n" +
+		"| ------------------------------------- | -------- | ------------------------ | ---------------------------------------------------- |\n" +
+		"| `bitwarden.command`                   | string   | `bw`                     | Bitwarden CLI command                                |\n" +
+		"| `cd.args`                             | []string | *none*                   | Extra args to shell in `cd` command                  |\n" +
+		"| `cd.command`                          | string   | *none*                   | Shell to run in `cd` command                         |\n" +
+		"| `color`                               | string   | `auto`                   | Colorize diffs                                       |\n" +
+		"| `data`                                | any      | *none*                   | Template data                                        |\n" +
+		"| `destDir`                             | string   | `~`                      | Destination directory                                |\n" +
+		"| `diff.args`                           | []string | *none*                   | Args to the external diff command                    |\n" +
+		"| `diff.command`                        | string   | *none*                   | External diff command                                |\n" +
+		"| `diff.commandPager`                   | bool     | `false`                  | Pipe external diff command output to the pager       |\n" +
+		"| `diff.dirDiff`                        | bool     | `false`                  | Run the external diff command once on directories    |\n" +
+		"| `diff.format`                         | string   | `chezmoi`                | Diff format, either `chezmoi` or `git`               |\n" +
+		"| `diff.pager`                          | string   | *none*                   | Pager                                                |\n" +
+		"| `dryRun`                              | bool     | `false`                  | Dry run mode                                         |\n" +
+		"| `follow`                              | bool     | `false`                  | Follow symlinks                                      |\n" +
+		"| `genericSecret.command`               | string   | *none*                   | Generic secret command                               |\n" +
+		"| `gopass.command`                      | string   | `gopass`                 | gopass CLI command                                   |\n" +
+		"| `gpg.command`                         | string   | `gpg`                    | GPG CLI command                                      |\n" +
+		"| `gpg.recipient`                       | string   | *none*                   | GPG recipient                                        |\n" +
+		"| `gpg.symmetric`                       | bool     | `false`                  | Use symmetric GPG encryption                         |\n" +
+		"| `interpreters.`*ext*`.args`           | []string | *none*                   | Extra args to the interpreter for *ext* scripts      |\n" +
+		"| `interpreters.`*ext*`.command`        | string   | *none*                   | Interpreter for scripts with extension *ext*         |\n" +
+		"| `keepassxc.args`                      | []string | *none*                   | Extra args to KeePassXC CLI command                  |\n" +
+		"| `keepassxc.command`                   | string   | `keepassxc-cli`          | KeePassXC CLI command                                |\n" +
+		"| `keepassxc.database`                  | string   | *none*                   | KeePassXC database                                   |\n" +
+		"| `lastpass.command`                    | string   | `lpass`                  | Lastpass CLI command                                 |\n" +
+		"| `merge.args`                          | []string | *none*                   | Extra args to 3-way merge command                    |\n" +
+		"| `merge.command`                       | string   | `vimdiff`                | 3-way merge command                                  |\n" +
+		"| `onepassword.command`                 | string   | `op`                     | 1Password CLI command                                |\n" +
+		"| `pass.command`                        | string   | `pass`                   | Pass CLI command                                     |\n" +
+		"| `remove`                              | bool     | `false`                  | Remove targets                                       |\n" +
+		"| `scriptDataFile`                      | bool     | `false`                  | Pass the template data to scripts in a file          |\n" +
+		"| `scriptEnv`                           | map      | *none*                   | Extra environment variables for scripts              |\n" +
+		"| `scriptErrors`                        | string   | `abort`                  | What to do when a script fails                       |\n" +
+		"| `scriptLogs`                          | int      | `0`                      | Number of script output logs to keep                 |\n" +
+		"| `scriptTimeout`                       | duration | *none*                   | Maximum time to run each script                      |\n" +
+		"| `sourceDir`                           | string   | `~/.local/share/chezmoi` | Source directory                                     |\n" +
+		"| `sourceVCS.autoCommit`                | bool     | `false`                  | Commit changes to the source state after any change  |\n" +
+		"| `sourceVCS.autoPush`                  | bool     | `false`                  | Push changes to the source state after any change    |\n" +
+		"| `sourceVCS.backends`                  | map      | *none*                   | Extra VCSes, see below                               |\n" +
+		"| `sourceVCS.command`                   | string   | `git`                    | Source version control system, or `builtin`          |\n" +
+		"| `sourceVCS.commitMessagePrefixes`     | map      | *none*                   | Commit message prefixes by command                   |\n" +
+		"| `sourceVCS.commitMessageTemplate`     | string   | *none*                   | Commit message template                              |\n" +
+		"| `sourceVCS.commitMessageTemplateFile` | string   | *none*                   | Commit message template file in the source directory |\n" +
+		"| `sourceVCS.editCommitMessage`         | bool     | `false`                  | Edit commit messages before committing               |\n" +
+		"| `sourceVCS.warnOnApply`               | bool     | `false`                  | Warn before applying if the source is not in sync    |\n" +
+		"| `template.options`                    | []string | `[\"missingkey=error\"]`   | Template options                                     |\n" +
+		"| `umask`                               | int      | *from system*            | Umask                                                |\n" +
+		"| `update.allowedSignersFile`           | string   | *none*                   | SSH allowed signers file for verifying commits       |\n" +
+		"| `update.keyringFile`                  | string   | *none*                   | GPG keyring file for verifying commits               |\n" +
+		"| `update.verifyAllCommits`             | bool     | `false`                  | Verify the signature of every new commit             |\n" +
+		"| `update.verifySignatures`             | bool     | `false`                  | Verify the signature of the new HEAD                 |\n" +
+		"| `vault.command`                       | string   | `vault`                  | Vault CLI command                                    |\n" +
+		"| `verbose`                             | bool     | `false`                  | Verbose mode                                         |\n" +
+		"\n" +
+		"### Source VCS backends\n" +
+		"\n" +
+		"chezmoi supports git, Mercurial (`hg`), and a builtin git (`builtin`) as source\n" +
+		"VCSes. Other VCSes can be defined in `sourceVCS.backends`, keyed by the basename\n" +
+		"of `sourceVCS.command`. Each of `add`, `clone`, `commit`, `init`, `pull`,\n" +
+		"`push`, `status`, and `version` is a list of arguments, each of which is a\n" +
+		"template. The templates can use `.Path` (the path to add), `.Repo` and `.Dir`\n" +
+		"(the repo to clone and the directory to clone it into), and `.Message` (the\n" +
+		"commit message). An operation with no arguments is not supported. chezmoi\n" +
+		"checks that every template executes when it reads the definition, and reports\n" +
+		"an error if one does not, for example if it refers to an unknown field.\n" +
+		"\n" +
+		"`statusFormat` determines how the output of `status` is parsed to generate\n" +
+		"commit messages, and is either `git` (the output of `git status\n" +
+		"--porcelain=v2`) or `hg` (the output of `hg status`). `versionRegexp` extracts\n" +
+		"the version from the output of `version` for `chezmoi doctor`. For example, to\n" +
+		"use a wrapper script `mygit` around git:\n" +
+		"\n" +
+		"    [sourceVCS]\n" +
+		"      command = \"mygit\"\n" +
+		"      autoCommit = true\n" +
+		"    [sourceVCS.backends.mygit]\n" +
+		"      add = [\"add\", \"{{ .Path }}\"]\n" +
+		"      clone = [\"clone\", \"{{ .Repo }}\", \"{{ .Dir }}\"]\n" +
+		"      commit = [\"commit\", \"--message\", \"{{ .Message }}\"]\n" +
+		"      init = [\"init\"]\n" +
+		"      pull = [\"pull\", \"--rebase\"]\n" +
+		"      push = [\"push\"]\n" +
+		"      status = [\"status\", \"--porcelain=v2\"]\n" +
+		"      statusFormat = \"git\"\n" +
+		"      version = [\"version\"]\n" +
+		"      versionRegexp = '^git version (\\d+\\.\\d+\\.\\d+)'\n" +
+		"\n" +
+		"A definition in `sourceVCS.backends` takes precedence over chezmoi's own support\n" +
+		"for a VCS with the same name.\n" +
+		"\n" +
+		"### Commit messages\n" +
+		"\n" +
+		"When `sourceVCS.autoCommit` or `sourceVCS.autoPush` is `true`, chezmoi generates\n" +
+		"commit messages from a template. By default, chezmoi uses a builtin template\n" +
+		"that describes each changed file, for example `Add dot_bashrc`. To use your own\n" +
+		"template, set either `sourceVCS.commitMessageTemplate` to the template itself\n" +
+		"or `sourceVCS.commitMessageTemplateFile` to the path of a file containing the\n" +
+		"template, relative to the source directory. The file's name should begin with a\n" +
+		"`.` so that chezmoi does not treat it as a target.\n" +
+		"\n" +
+		"The template is executed with the following data:\n" +
+		"\n" +
+		"| Field                   | Type     | Value                                                              |\n" +
+		"| ----------------------- | -------- | ------------------------------------------------------------------ |\n" +
+		"| `.Command`              | string   | The chezmoi command that made the changes, e.g. `add`              |\n" +
+		"| `.Prefix`               | string   | The prefix for `.Command` from `commitMessagePrefixes`             |\n" +
+		"| `.Targets`              | []object | The targets passed to the command                                  |\n" +
+		"| `.Targets[].Path`       | string   | The target path, relative to the destination directory             |\n" +
+		"| `.Targets[].SourcePath` | string   | The source path, relative to the source directory                  |\n" +
+		"| `.Targets[].Attributes` | []string | The target's attributes, e.g. `private` or `template`              |\n" +
+		"| `.Ordinary`, etc.       | []object | The status of the source directory, as `git status --porcelain=v2` |\n" +
+		"\n" +
+		"The source path and attributes of targets that are no longer in the source\n" +
+		"state, for example after `chezmoi forget`, are empty.\n" +
+		"\n" +
+		"`sourceVCS.commitMessagePrefixes` maps command names to [conventional\n" +
+		"commit](https://www.conventionalcommits.org/) prefixes. With the builtin\n" +
+		"template, the prefix is added to the start of the message followed by `: `.\n" +
+		"Custom templates can use `.Prefix` as they wish. For example:\n" +
+		"\n" +
+		"    [sourceVCS]\n" +
+		"      autoCommit = true\n" +
+		"      commitMessageTemplate = \"{{ .Prefix }}: {{ .Command }} {{ range .Targets }}{{ .Path }} {{ end }}\"\n" +
+		"      [sourceVCS.commitMessagePrefixes]\n" +
+		"        add = \"feat\"\n" +
+		"        forget = \"chore\"\n" +
+		"\n" +
+		"If `sourceVCS.editCommitMessage` is `true` then chezmoi opens each commit\n" +
+		"message in your editor before committing. Saving an empty message aborts the\n" +
+		"commit.\n" +
 		"\n" +
 		"## Source state attributes\n" +
 		"\n" +
@@ -1855,6 +2128,12 @@ func init() {
 		"to create an initial config file. *format* must be one of the the supported\n" +
 		"config file formats.\n" +
 		"\n" +
+		"When `chezmoi init` generates the config file, it records a hash of the config\n" +
+		"template in its persistent state. If the config template subsequently changes,\n" +
+		"for example after pulling changes to your source directory, then chezmoi will\n" +
+		"warn you that your config file may be out of date. Run `chezmoi init\n" +
+		"--regenerate-config` to regenerate it.\n" +
+		"\n" +
 		"#### `.chezmoi.<format>.tmpl` examples\n" +
 		"\n" +
 		"    {{ $email := promptString \"email\" -}}\n" +
@@ -1905,7 +2184,12 @@ func init() {
 		"\n" +
 		"If a directory called `.chezmoitemplates` exists, then all files in this\n" +
 		"directory are parsed as templates are available as templates with a name equal\n" +
-		"to the relative path of the file.\n" +
+		"to the relative path of the file. Templates in `.chezmoitemplates` can use all\n" +
+		"template functions and are parsed with the same options as other templates.\n" +
+		"\n" +
+		"Templates can be included with the `template` action, or with the\n" +
+		"[`includeTemplate`](#includetemplate-name-data) function to pass them custom\n" +
+		"data. Templates must not include themselves, directly or indirectly.\n" +
 		"\n" +
 		"#### `.chezmoitemplates` examples\n" +
 		"\n" +
@@ -1919,6 +2203,72 @@ func init() {
 		"\n" +
 		"The target state of `.config` will be `bar`.\n" +
 		"\n" +
+		"Given:\n" +
+		"\n" +
+		"    .chezmoitemplates/greeting\n" +
+		"    Hello, {{ .name }}!\n" +
+		"\n" +
+		"    dot_motd.tmpl\n" +
+		"    {{ includeTemplate \"greeting\" (dict \"name\" \"world\") }}\n" +
+		"\n" +
+		"The target state of `.motd` will be `Hello, world!`.\n" +
+		"\n" +
+		"### `.chezmoitests`\n" +
+		"\n" +
+		"If a directory called `.chezmoitests` exists, then each of its subdirectories\n" +
+		"is a test case for `chezmoi test`. A test case directory may contain:\n" +
+		"\n" +
+		"* `data.<format>`, template data, in any of the supported config file formats,\n" +
+		"  that is merged into the template data before rendering the target state, as\n" +
+		"  if it had been passed with `--data-file`.\n" +
+		"* `targets/`, a directory of golden files. Each file is compared with the\n" +
+		"  rendered contents of the target with the same relative path. For symlinks,\n" +
+		"  the golden file contains the link's target followed by a newline.\n" +
+		"* `archive.txt`, a golden file compared with a textual listing of the whole\n" +
+		"  target state. Each directory and file is introduced with a\n" +
+		"  `==> name mode <==` line followed by the file's contents, and each symlink\n" +
+		"  with a `==> name -> linkname <==` line.\n" +
+		"\n" +
+		"The target state is always rendered with a umask of `022`, so golden files do\n" +
+		"not depend on the machine that the tests are run on.\n" +
+		"\n" +
+		"#### `.chezmoitests` examples\n" +
+		"\n" +
+		"    .chezmoitests/work/data.yaml\n" +
+		"    email: \"john.smith@company.com\"\n" +
+		"\n" +
+		"    .chezmoitests/work/targets/.gitconfig\n" +
+		"    [user]\n" +
+		"        email = john.smith@company.com\n" +
+		"\n" +
+		"### `.chezmoitriggers`\n" +
+		"\n" +
+		"If a file called `.chezmoitriggers` exists in the source state then it is\n" +
+		"interpreted as a list of triggers, one per line. Each trigger is a target\n" +
+		"pattern followed by a command, for example:\n" +
+		"\n" +
+		"    # pattern                       command\n" +
+		"    .tmux.conf                      tmux source-file ~/.tmux.conf\n" +
+		"    .local/share/fonts              fc-cache\n" +
+		"    .config/systemd/user/*.service  systemctl --user daemon-reload\n" +
+		"\n" +
+		"After all targets have been applied, `chezmoi apply` runs each trigger whose\n" +
+		"pattern matches a target, or a parent directory of a target, that was changed.\n" +
+		"Patterns are relative to the directory containing `.chezmoitriggers` and can\n" +
+		"use `**` to match any number of directories. Triggers are run in the order in\n" +
+		"which they are listed, and each command is run at most once.\n" +
+		"\n" +
+		"Commands are run with `sh` in the destination directory, with the same\n" +
+		"environment variables, timeout, and error handling as scripts. A command of the\n" +
+		"form `script:`*path* instead runs the script at *path*, relative to the\n" +
+		"directory containing `.chezmoitriggers` in the source state, for example\n" +
+		"`script:.chezmoiscripts/reload.sh`. If *path* has a `.tmpl` suffix then the\n" +
+		"script is interpreted as a template.\n" +
+		"\n" +
+		"`.chezmoitriggers` is interpreted as a template. `chezmoi diff` lists the\n" +
+		"triggers that would be run. `chezmoi diff` and `chezmoi verify` never run\n" +
+		"triggers.\n" +
+		"\n" +
 		"### `.chezmoiversion`\n" +
 		"\n" +
 		"If a file called `.chezmoiversion` exists, then its contents are interpreted as\n" +
@@ -1983,11 +2333,50 @@ func init() {
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+		"instead of from the source directory. The source directory is not changed, so\n" +
+		"this can be used to roll back to an earlier version of your dotfiles.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
 		"    chezmoi apply ~/.bashrc\n" +
+		"    chezmoi apply --source-ref HEAD~1\n" +
+		"    chezmoi apply --exclude=scripts\n" +
+		"\n" +
+		"### `apply-patch` [*patch*]\n" +
+		"\n" +
+		"Apply the git patch *patch* to the destination directory. If *patch* is not\n" +
+		"given or is `-`, the patch is read from the standard input. Patches written by\n" +
+		"`chezmoi diff --format=git` can be applied, so changes can be reviewed and\n" +
+		"then applied on a machine without access to your source repo. The patch must\n" +
+		"apply exactly: each file's contents must match the index line and the hunk\n" +
+		"context, otherwise nothing is changed. Binary patches must be literal, as\n" +
+		"written by `chezmoi diff` and `git diff --binary`, and copies are not\n" +
+		"supported. Git patches only record whether a file is executable, so existing\n" +
+		"files keep their permissions, apart from the executable bits if the patch\n" +
+		"changes the file's mode.\n" +
+		"\n" +
+		"#### `apply-patch` examples\n" +
+		"\n" +
+		"    chezmoi diff --format=git > changes.patch\n" +
+		"    chezmoi apply-patch changes.patch\n" +
+		"    chezmoi apply-patch --dry-run --verbose < changes.patch\n" +
 		"\n" +
 		"### `archive`\n" +
 		"\n" +
@@ -1998,10 +2387,41 @@ func init() {
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
 		"\n" +
+		"#### `--data-file` *filename*\n" +
+		"\n" +
+		"Override the template data with the data in *filename*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--override-data` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data at *key* with the string *value*, or, if given as\n" +
+		"*key*`:=`*json*, with the value of *json*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+		"instead of from the source directory.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
 		"#### `archive` examples\n" +
 		"\n" +
 		"    chezmoi archive | tar tvf -\n" +
 		"    chezmoi archive --output=dotfiles.tar\n" +
+		"    chezmoi archive --data-file=laptop.yaml | tar tvf -\n" +
+		"    chezmoi archive --source-ref v1.0.0 --output=dotfiles.tar\n" +
+		"    chezmoi archive --glob '.config/**' --output=config.tar\n" +
 		"\n" +
 		"### `cat` targets\n" +
 		"\n" +
@@ -2009,16 +2429,35 @@ func init() {
 		"symlinks. For files, the target file contents are written. For symlinks, the\n" +
 		"target target is written.\n" +
 		"\n" +
+		"#### `--data-file` *filename*\n" +
+		"\n" +
+		"Override the template data with the data in *filename*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--override-data` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data at *key* with the string *value*, or, if given as\n" +
+		"*key*`:=`*json*, with the value of *json*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+		"instead of from the source directory.\n" +
+		"\n" +
 		"#### `cat` examples\n" +
 		"\n" +
 		"    chezmoi cat ~/.bashrc\n" +
+		"    chezmoi cat --override-data chezmoi.hostname=ci-runner ~/.gitconfig\n" +
+		"    chezmoi cat --source-ref HEAD~1 ~/.bashrc\n" +
 		"\n" +
 		"### `cd`\n" +
 		"\n" +
 		"Launch a shell in the source directory. chezmoi will launch the command set by\n" +
 		"the `cd.command` configuration variable with any extra arguments specified by\n" +
 		"`cd.args`. If this is not set, chezmoi will attempt to detect your shell and\n" +
-		"will finally fall back to an OS-specific default.\n" +
+		"will finally fall back to an OS-specific default. The shell is run with the\n" +
+		"same [environment variables as scripts](#script-environment-variables).\n" +
 		"\n" +
 		"#### `cd` examples\n" +
 		"\n" +
@@ -2074,6 +2513,17 @@ func init() {
 		"Print the computed template data in the given format. The accepted formats are\n" +
 		"`json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `--data-file` *filename*\n" +
+		"\n" +
+		"Override the template data with the data in *filename*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--override-data` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data at *key* with the string *value*, or, if given as\n" +
+		"*key*`:=`*json*, with the value of *json*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
 		"#### `data` examples\n" +
 		"\n" +
 		"    chezmoi data\n" +
@@ -2087,6 +2537,33 @@ func init() {
 		"If a `diff.pager` command is set in the configuration file then the output will\n" +
 		"be piped into it.\n" +
 		"\n" +
+		"Any [triggers](#chezmoitriggers) that would be run are listed after the diff.\n" +
+		"\n" +
+		"If a `diff.command` is set in the configuration file then it is used instead of\n" +
+		"the builtin diff, and `--format` is ignored. The command is run once for each\n" +
+		"changed file with the arguments in `diff.args`, which are templates in which\n" +
+		"`{{ .Destination }}` is a temporary copy of the destination file and\n" +
+		"`{{ .Target }}` is a temporary file holding the target contents. The contents\n" +
+		"of a symlink are its target. If a file does not exist on one side then\n" +
+		"`/dev/null` is used instead. `diff.args` defaults to `[\"{{ .Destination }}\",\n" +
+		"\"{{ .Target }}\"]`. The exit status of the command is ignored. The command is\n" +
+		"run with your terminal, so interactive tools like `vimdiff` work, and its output\n" +
+		"is only piped into `diff.pager` if `diff.commandPager` is `true`, which is\n" +
+		"useful for tools that only print a diff. For example, to use\n" +
+		"[delta](https://github.com/dandavison/delta) with `less` as the pager:\n" +
+		"\n" +
+		"    [diff]\n" +
+		"        command = \"delta\"\n" +
+		"        commandPager = true\n" +
+		"        pager = \"less -R\"\n" +
+		"\n" +
+		"#### `--dir-diff`\n" +
+		"\n" +
+		"Run `diff.command` once, with `{{ .Destination }}` and `{{ .Target }}` set to\n" +
+		"temporary directories containing all changed files, instead of once per file.\n" +
+		"This can also be set with the `diff.dirDiff` variable in the configuration\n" +
+		"file.\n" +
+		"\n" +
 		"#### `-f`, `--format` *format*\n" +
 		"\n" +
 		"Print the diff in *format*. The format can be set with the `diff.format`\n" +
@@ -2103,15 +2580,50 @@ func init() {
 		"version 2.0.0 of chezmoi, `git` format diffs will become the default and include\n" +
 		"scripts and the `chezmoi` format will be removed.\n" +
 		"\n" +
+		"The diff includes full blob hashes and binary patches, so it can be applied with\n" +
+		"`git apply` or `chezmoi apply-patch`. As git does not track directories, they\n" +
+		"are not included, and changes that git cannot record, such as a change from\n" +
+		"mode `0644` to `0600`, are omitted.\n" +
+		"\n" +
 		"#### `--no-pager`\n" +
 		"\n" +
 		"Do not use the pager.\n" +
 		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+		"instead of from the source directory.\n" +
+		"\n" +
+		"#### `--between` *revision1* *revision2*\n" +
+		"\n" +
+		"Instead of comparing with the destination state, print the difference between\n" +
+		"the target states of two git revisions of the source directory, for example to\n" +
+		"see what your dotfiles looked like last week. The arguments are the revisions\n" +
+		"rather than targets, and the diff is always a unified diff of the target\n" +
+		"contents.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
 		"#### `diff` examples\n" +
 		"\n" +
 		"    chezmoi diff\n" +
 		"    chezmoi diff ~/.bashrc\n" +
 		"    chezmoi diff --format=git\n" +
+		"    chezmoi diff --dir-diff\n" +
+		"    chezmoi diff --source-ref HEAD~1\n" +
+		"    chezmoi diff --between 'HEAD@{1.week.ago}' HEAD\n" +
+		"    chezmoi diff --glob '.config/**'\n" +
+		"\n" +
 		"\n" +
 		"### `docs` [*regexp*]\n" +
 		"\n" +
@@ -2142,10 +2654,42 @@ func init() {
 		"Print the target state in the given format. The accepted formats are `json`\n" +
 		"(JSON) and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `--data-file` *filename*\n" +
+		"\n" +
+		"Override the template data with the data in *filename*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--override-data` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data at *key* with the string *value*, or, if given as\n" +
+		"*key*`:=`*json*, with the value of *json*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+		"instead of from the source directory.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
 		"#### `dump` examples\n" +
 		"\n" +
 		"    chezmoi dump ~/.bashrc\n" +
 		"    chezmoi dump --format=yaml\n" +
+		"    chezmoi dump --data-file=laptop.yaml --override-data chezmoi.os=darwin\n" +
+		"    chezmoi dump --source-ref HEAD~1 ~/.bashrc\n" +
+		"    chezmoi dump --include=templates\n" +
+		"\n" +
 		"\n" +
 		"### `edit` [*targets*]\n" +
 		"\n" +
@@ -2195,12 +2739,47 @@ func init() {
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
 		"\n" +
+		"#### `--promptBool` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptBool` function with a function that returns values from\n" +
+		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptBool` is called with a *prompt* that does not match any of *pairs*, then\n" +
+		"it returns its default value if given, or `false` otherwise.\n" +
+		"\n" +
+		"#### `--promptChoice` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptChoice` function with a function that returns values from\n" +
+		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptChoice` is called with a *prompt* that does not match any of *pairs*,\n" +
+		"then it returns its default value if given, or the first choice otherwise.\n" +
+		"\n" +
+		"#### `--promptInt` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptInt` function with a function that returns values from\n" +
+		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptInt` is called with a *prompt* that does not match any of *pairs*, then\n" +
+		"it returns its default value if given, or `0` otherwise.\n" +
+		"\n" +
 		"#### `--promptString`, `-p` *pairs*\n" +
 		"\n" +
 		"Simulate the `promptString` function with a function that returns values from\n" +
 		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
 		"`promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
-		"then it returns *prompt* unchanged.\n" +
+		"then it returns its default value if given, or *prompt* unchanged otherwise.\n" +
+		"\n" +
+		"The `prompt*Once` functions are simulated in the same way, returning the\n" +
+		"existing value from the template data if present.\n" +
+		"\n" +
+		"#### `--data-file` *filename*\n" +
+		"\n" +
+		"Override the template data with the data in *filename*. See [template\n" +
+		"variables](#template-variables).\n" +
+		"\n" +
+		"#### `--override-data` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data at *key* with the string *value*, or, if given as\n" +
+		"*key*`:=`*json*, with the value of *json*. See [template\n" +
+		"variables](#template-variables).\n" +
 		"\n" +
 		"#### `execute-template` examples\n" +
 		"\n" +
@@ -2241,6 +2820,38 @@ func init() {
 		"\n" +
 		"    chezmoi hg -- pull --rebase --update\n" +
 		"\n" +
+		"### `history` *target*\n" +
+		"\n" +
+		"Print the changes to *target* in each commit of the git repository containing\n" +
+		"the source directory, newest first. At each commit, *target* is mapped to its\n" +
+		"source file, following renames made by `chezmoi chattr` and similar, and is\n" +
+		"rendered with the current template data, so the diffs show how the target\n" +
+		"itself changed. Commits that do not change *target* are skipped, as are the\n" +
+		"second and later parents of merges. Template functions that run commands are\n" +
+		"disabled for commits that you have not trusted.\n" +
+		"\n" +
+		"#### `--max-count` *count*\n" +
+		"\n" +
+		"Print at most *count* commits.\n" +
+		"\n" +
+		"#### `--no-pager`\n" +
+		"\n" +
+		"Do not use the pager.\n" +
+		"\n" +
+		"#### `--source`\n" +
+		"\n" +
+		"Print the changes to the source file instead of the rendered target.\n" +
+		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Start from the git *revision* instead of `HEAD`.\n" +
+		"\n" +
+		"#### `history` examples\n" +
+		"\n" +
+		"    chezmoi history ~/.bashrc\n" +
+		"    chezmoi history --max-count=3 ~/.gitconfig\n" +
+		"    chezmoi history --source ~/.ssh/config\n" +
+		"\n" +
 		"### `init` [*repo*]\n" +
 		"\n" +
 		"Setup the source directory and update the destination directory to match the\n" +
@@ -2251,10 +2862,92 @@ func init() {
 		"file is created using that file as a template. Finally, if the `--apply` flag is\n" +
 		"passed, `chezmoi apply` is run.\n" +
 		"\n" +
+		"Before the configuration file template is executed, chezmoi checks that the\n" +
+		"source state is [trusted](#source-state-trust).\n" +
+		"\n" +
+		"If *repo* is a GitHub username, for example `user`, then it is expanded to\n" +
+		"`https://github.com/user/dotfiles.git`. If *repo* is a GitHub username and repo\n" +
+		"name separated by a slash, for example `user/repo`, then it is expanded to\n" +
+		"`https://github.com/user/repo.git`.\n" +
+		"\n" +
+		"#### `--apply`\n" +
+		"\n" +
+		"Run `chezmoi apply` after checking out the repo and creating the config file.\n" +
+		"\n" +
+		"#### `--branch` *branch*\n" +
+		"\n" +
+		"Check out *branch* instead of the default branch.\n" +
+		"\n" +
+		"#### `-d`, `--depth` *depth*\n" +
+		"\n" +
+		"Clone the repo with depth *depth*, i.e. create a shallow clone. Only supported\n" +
+		"when the source VCS is `git`.\n" +
+		"\n" +
+		"#### `--one-shot`\n" +
+		"\n" +
+		"`chezmoi init --one-shot` attempts to install your dotfiles with a single\n" +
+		"command and then remove all traces of chezmoi from the system. This is useful\n" +
+		"for setting up your dotfiles on transitory machines like containers and CI\n" +
+		"runners. It is equivalent to `--apply --depth=1` followed by `chezmoi purge\n" +
+		"--force`.\n" +
+		"\n" +
+		"#### `--promptBool` *pairs*\n" +
+		"\n" +
+		"Populate the `promptBool` template function with values from *pairs*. *pairs*\n" +
+		"is a comma-separated list of *prompt*`=`*value* pairs. If `promptBool` is called\n" +
+		"with a *prompt* that does not match any of *pairs*, then it prompts the user for\n" +
+		"a value.\n" +
+		"\n" +
+		"#### `--promptChoice` *pairs*\n" +
+		"\n" +
+		"Populate the `promptChoice` template function with values from *pairs*.\n" +
+		"*pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+		"`promptChoice` is called with a *prompt* that does not match any of *pairs*,\n" +
+		"then it prompts the user for a value.\n" +
+		"\n" +
+		"#### `--promptDefaults`\n" +
+		"\n" +
+		"Make all `prompt*` template function calls with a default value return that\n" +
+		"default value instead of prompting.\n" +
+		"\n" +
+		"#### `--promptInt` *pairs*\n" +
+		"\n" +
+		"Populate the `promptInt` template function with values from *pairs*. *pairs* is\n" +
+		"a comma-separated list of *prompt*`=`*value* pairs. If `promptInt` is called\n" +
+		"with a *prompt* that does not match any of *pairs*, then it prompts the user for\n" +
+		"a value.\n" +
+		"\n" +
+		"#### `--promptString` *pairs*\n" +
+		"\n" +
+		"Populate the `promptString` template function with values from *pairs*. *pairs*\n" +
+		"is a comma-separated list of *prompt*`=`*value* pairs. If `promptString` is\n" +
+		"called with a *prompt* that does not match any of *pairs*, then it prompts the\n" +
+		"user for a value.\n" +
+		"\n" +
+		"#### `--regenerate-config`\n" +
+		"\n" +
+		"Regenerate the config file from the config template without checking out the\n" +
+		"repo. The data in the existing config file is available to the config template,\n" +
+		"so the `prompt*Once` template functions reuse previous answers and only prompt\n" +
+		"for new values.\n" +
+		"\n" +
+		"#### `--ssh`\n" +
+		"\n" +
+		"Use `git@github.com:user/repo.git` instead of\n" +
+		"`https://github.com/user/repo.git` when expanding a GitHub username or\n" +
+		"username and repo name.\n" +
+		"\n" +
 		"#### `init` examples\n" +
 		"\n" +
+		"    chezmoi init user\n" +
+		"    chezmoi init user --apply\n" +
+		"    chezmoi init user --apply --ssh\n" +
+		"    chezmoi init user/dots --branch main --depth 1\n" +
+		"    chezmoi init user --one-shot\n" +
+		"    chezmoi init --regenerate-config\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+		"    chezmoi init https://github.com/user/dotfiles.git --promptDefaults --promptString email=john@home.org\n" +
 		"\n" +
 		"### `import` *filename*\n" +
 		"\n" +
@@ -2286,6 +2979,29 @@ func init() {
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
 		"\n" +
+		"### `lint`\n" +
+		"\n" +
+		"Check the source state for problems without applying it. `lint` parses every\n" +
+		"template, `.chezmoiignore`, `.chezmoiremove`, and `.chezmoitemplates` file with\n" +
+		"the same template functions used by `apply`, and reports:\n" +
+		"\n" +
+		"* Template syntax errors and calls to undefined template functions.\n" +
+		"* References to template data, for example `.email`, that do not exist in the\n" +
+		"  template data of this machine or of any [`.chezmoitests`](#chezmoitests)\n" +
+		"  test case.\n" +
+		"* References to templates that are not defined.\n" +
+		"* Source names containing prefixes in the wrong order or prefixes that are not\n" +
+		"  valid for their type, which would otherwise silently become part of the\n" +
+		"  target name, for example `executable_private_dot_foo` or `exact_file`.\n" +
+		"\n" +
+		"Each problem is printed as a `file:line:col: message` diagnostic and chezmoi\n" +
+		"exits with a non-zero exit status if any problems are found. Encrypted files\n" +
+		"are not checked.\n" +
+		"\n" +
+		"#### `lint` examples\n" +
+		"\n" +
+		"    chezmoi lint\n" +
+		"\n" +
 		"### `manage` *targets*\n" +
 		"\n" +
 		"`manage` is an alias for `add` for symmetry with `unmanage`.\n" +
@@ -2296,10 +3012,16 @@ func init() {
 		"\n" +
 		"#### `-i`, `--include` *types*\n" +
 		"\n" +
-		"Only list entries of type *types*. *types* is a comma-separated list of types of\n" +
-		"entry to include. Valid types are `dirs`, `files`, and `symlinks` which can be\n" +
-		"abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will list\n" +
-		"entries of all types.\n" +
+		"Only list entries of type *types*. See [entry filters](#entry-filters). By\n" +
+		"default, `managed` lists directories, files, and symlinks.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Do not list entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only list targets matching *pattern*. See [entry filters](#entry-filters).\n" +
 		"\n" +
 		"#### `managed` examples\n" +
 		"\n" +
@@ -2308,6 +3030,8 @@ func init() {
 		"    chezmoi managed --include=files,symlinks\n" +
 		"    chezmoi managed -i d\n" +
 		"    chezmoi managed -i d,f\n" +
+		"    chezmoi managed --include=scripts\n" +
+		"    chezmoi managed --exclude=encrypted --glob '.config/**'\n" +
 		"\n" +
 		"### `merge` *targets*\n" +
 		"\n" +
@@ -2394,6 +3118,94 @@ func init() {
 		"    chezmoi source-path\n" +
 		"    chezmoi source-path ~/.bashrc\n" +
 		"\n" +
+		"### `source-status`\n" +
+		"\n" +
+		"Print the sync state of the source repository: the current branch and its\n" +
+		"upstream, how many commits the branch is ahead of or behind its upstream,\n" +
+		"whether there are uncommitted changes, and the number of stashes. The state is\n" +
+		"one of `up-to-date`, `ahead`, `behind`, `diverged`, or `no-upstream`. The source\n" +
+		"VCS must be `git`. The state is determined from the local repository, so run\n" +
+		"`chezmoi source fetch` first to see new commits in the upstream.\n" +
+		"\n" +
+		"If `update.verifySignatures` is `true`, the source status also reports whether\n" +
+		"the signature of HEAD is `verified` or `unverified`, using the same keys as\n" +
+		"[`chezmoi update`](#update).\n" +
+		"\n" +
+		"`chezmoi doctor` includes the source status, and, if `sourceVCS.warnOnApply` is\n" +
+		"`true`, `chezmoi apply` prints a warning if the source has uncommitted changes\n" +
+		"or is ahead of or behind its upstream.\n" +
+		"\n" +
+		"#### `-f`, `--format` `text`|`json`|`toml`|`yaml`\n" +
+		"\n" +
+		"Print the source status in the given format. The default is `text`. The `json`\n" +
+		"format is suitable for use in shell prompts.\n" +
+		"\n" +
+		"#### `source-status` examples\n" +
+		"\n" +
+		"    chezmoi source-status\n" +
+		"    chezmoi source-status --format=json\n" +
+		"\n" +
+		"### `sync`\n" +
+		"\n" +
+		"Synchronize the source state, the destination directory, and the remote repo in\n" +
+		"one step. chezmoi:\n" +
+		"\n" +
+		"1. Pulls changes from the source VCS.\n" +
+		"2. Adds back any target that has been edited in the destination directory since\n" +
+		"   it was last applied. If the target has also changed in the source state, or\n" +
+		"   is a template, or chezmoi does not know its contents when it was last\n" +
+		"   applied, then the merge command is run instead, as for `chezmoi merge`.\n" +
+		"3. Commits any changes to the source directory with an automatically-generated\n" +
+		"   commit message and pushes them.\n" +
+		"4. Applies the resulting target state.\n" +
+		"\n" +
+		"With `--dry-run`, chezmoi prints what each step would do without changing\n" +
+		"anything.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `sync` examples\n" +
+		"\n" +
+		"    chezmoi sync\n" +
+		"    chezmoi sync --dry-run\n" +
+		"    chezmoi sync --glob '.config/**'\n" +
+		"\n" +
+		"### `test` [*cases*]\n" +
+		"\n" +
+		"Render the target state with the data from each test case in the\n" +
+		"`.chezmoitests` directory in the source directory and compare the result with\n" +
+		"the case's golden files. If no cases are specified then all cases are run.\n" +
+		"Differences are printed as unified diffs, and chezmoi exits with a non-zero\n" +
+		"exit status if any test fails. See [`.chezmoitests`](#chezmoitests) for the\n" +
+		"layout of a test case.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the results in the given format. The accepted formats are `text`, `tap`\n" +
+		"(Test Anything Protocol), and `junit` (JUnit XML).\n" +
+		"\n" +
+		"#### `-u`, `--update`\n" +
+		"\n" +
+		"Update the golden files with the rendered target state instead of reporting\n" +
+		"differences.\n" +
+		"\n" +
+		"#### `test` examples\n" +
+		"\n" +
+		"    chezmoi test\n" +
+		"    chezmoi test work\n" +
+		"    chezmoi test --update\n" +
+		"    chezmoi test --format=junit > report.xml\n" +
+		"\n" +
 		"### `unmanage` *targets*\n" +
 		"\n" +
 		"`unmanage` is an alias for `forget` for symmetry with `manage`.\n" +
@@ -2408,11 +3220,71 @@ func init() {
 		"\n" +
 		"### `update`\n" +
 		"\n" +
-		"Pull changes from the source VCS and apply any changes.\n" +
+		"Pull changes from the source VCS and apply any changes. If the source VCS is git\n" +
+		"then any submodules are initialized and updated after pulling.\n" +
+		"\n" +
+		"After pulling, chezmoi prints a summary of the targets that were added,\n" +
+		"modified, or removed in the target state, the scripts that will be run, and the\n" +
+		"new minimum version of chezmoi if `.chezmoiversion` changed.\n" +
+		"\n" +
+		"If the source VCS is git and any step fails before chezmoi starts applying\n" +
+		"changes, for example because the new source state contains an invalid template,\n" +
+		"then the source directory is restored to the previous HEAD.\n" +
+		"\n" +
+		"#### `-a`, `--apply`\n" +
+		"\n" +
+		"Apply changes after pulling. This is the default, use `--apply=false` to only\n" +
+		"pull.\n" +
+		"\n" +
+		"#### `-d`, `--diff`\n" +
+		"\n" +
+		"Print the difference between the target state before and after pulling.\n" +
+		"\n" +
+		"#### `-p`, `--prompt`\n" +
+		"\n" +
+		"Prompt before applying changes. Implies `--diff`. If you decline then the\n" +
+		"changes remain in the source directory and can be applied later with `chezmoi\n" +
+		"apply`.\n" +
+		"\n" +
+		"If `update.verifySignatures` is `true` in the configuration file then, after\n" +
+		"pulling, chezmoi runs `git verify-commit` on the new HEAD, or on every new\n" +
+		"commit if `update.verifyAllCommits` is also `true`. If any signature is missing\n" +
+		"or invalid then the source directory is restored to the previous HEAD and no\n" +
+		"changes are applied. Only git is supported.\n" +
+		"\n" +
+		"SSH signatures are checked against the `update.allowedSignersFile` file, in the\n" +
+		"format described in `ssh-keygen(1)`. GPG signatures are checked against the\n" +
+		"keys in the `update.keyringFile` file, which can be exported with `gpg --export`\n" +
+		"and is imported into a temporary keyring. If only one of them is set then\n" +
+		"signatures in the other format are always rejected. If neither is set, git's own\n" +
+		"signature configuration is used, so a commit signed by any key in your default\n" +
+		"GnuPG keyring or git's allowed signers file is accepted, and `chezmoi doctor`\n" +
+		"warns about this. For example:\n" +
+		"\n" +
+		"    [update]\n" +
+		"      verifySignatures = true\n" +
+		"      allowedSignersFile = \"/home/user/.config/chezmoi/allowed_signers\"\n" +
+		"\n" +
+		"`chezmoi doctor` reports whether signature verification is enabled.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
 		"\n" +
 		"#### `update` examples\n" +
 		"\n" +
 		"    chezmoi update\n" +
+		"    chezmoi update --prompt\n" +
+		"    chezmoi update --diff --apply=false\n" +
+		"    chezmoi update --exclude=scripts\n" +
 		"\n" +
 		"### `upgrade`\n" +
 		"\n" +
@@ -2442,10 +3314,23 @@ func init() {
 		"(success) if all targets match their target state, or 1 (failure) otherwise. If\n" +
 		"no targets are specified then all targets are checked.\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. See [entry filters](#entry-filters).\n" +
+		"\n" +
+		"#### `--glob` *pattern*\n" +
+		"\n" +
+		"Only include targets matching *pattern*. See [entry filters](#entry-filters).\n" +
+		"\n" +
 		"#### `verify` examples\n" +
 		"\n" +
 		"    chezmoi verify\n" +
 		"    chezmoi verify ~/.bashrc\n" +
+		"    chezmoi verify --exclude=encrypted\n" +
 		"\n" +
 		"## Editor configuration\n" +
 		"\n" +
@@ -2465,6 +3350,129 @@ func init() {
 		"\n" +
 		"    umask = 0o22\n" +
 		"\n" +
+		"## Script environment variables\n" +
+		"\n" +
+		"Scripts and shells launched by `chezmoi cd` are run with the following\n" +
+		"environment variables set, in addition to chezmoi's own environment:\n" +
+		"\n" +
+		"| Variable              | Value                                                    |\n" +
+		"| --------------------- | -------------------------------------------------------- |\n" +
+		"| `CHEZMOI`             | `1`                                                      |\n" +
+		"| `CHEZMOI_ARCH`        | The architecture, as in `.chezmoi.arch`                  |\n" +
+		"| `CHEZMOI_CONFIG_FILE` | The path to the config file                              |\n" +
+		"| `CHEZMOI_DATA_FILE`   | The path to the template data in JSON format, if enabled |\n" +
+		"| `CHEZMOI_DEST_DIR`    | The destination directory                                |\n" +
+		"| `CHEZMOI_EXECUTABLE`  | The path to the chezmoi executable                       |\n" +
+		"| `CHEZMOI_OS`          | The operating system, as in `.chezmoi.os`                |\n" +
+		"| `CHEZMOI_SOURCE_DIR`  | The source directory                                     |\n" +
+		"\n" +
+		"`CHEZMOI_DATA_FILE` is only set if the `scriptDataFile` configuration variable\n" +
+		"is `true`, and the file is not written in dry run mode. The file is removed\n" +
+		"when chezmoi exits.\n" +
+		"\n" +
+		"Extra variables can be set with the `scriptEnv` configuration variable. As\n" +
+		"configuration keys are case insensitive, their names are converted to upper\n" +
+		"case. They cannot override the variables above.\n" +
+		"\n" +
+		"    [scriptEnv]\n" +
+		"      EDITOR = \"vim\"\n" +
+		"\n" +
+		"## Script errors and timeouts\n" +
+		"\n" +
+		"By default, `chezmoi apply` stops at the first script that fails. The\n" +
+		"`scriptErrors` configuration variable controls this:\n" +
+		"\n" +
+		"| Value      | Effect                                                   |\n" +
+		"| ---------- | -------------------------------------------------------- |\n" +
+		"| `abort`    | Stop at the first failing script                         |\n" +
+		"| `continue` | Continue applying, and report all failures at the end    |\n" +
+		"| `prompt`   | Ask whether to continue after each failing script        |\n" +
+		"\n" +
+		"If any script failed and chezmoi continued, `chezmoi apply` lists the failed\n" +
+		"scripts and exits with a non-zero status. `run_once_` scripts that fail are run\n" +
+		"again the next time.\n" +
+		"\n" +
+		"The `scriptTimeout` configuration variable sets the maximum time that each\n" +
+		"script is allowed to run, for example `scriptTimeout = \"5m\"`. Scripts that run\n" +
+		"for longer are killed and treated as failed. A script can set its own timeout\n" +
+		"with a `chezmoi:script:timeout=`*duration* directive anywhere in its contents,\n" +
+		"for example:\n" +
+		"\n" +
+		"    #!/bin/sh\n" +
+		"    # chezmoi:script:timeout=30s\n" +
+		"\n" +
+		"If the `scriptLogs` configuration variable is greater than zero, then the output\n" +
+		"of scripts run by `chezmoi apply` is also written to a log file in the `logs`\n" +
+		"directory next to chezmoi's persistent state, by default\n" +
+		"`~/.config/chezmoi/logs/scripts-`*timestamp*`.log`. A log file is only created\n" +
+		"if a script produces output, and only the newest `scriptLogs` log files are\n" +
+		"kept. Logging connects the scripts' standard output and standard error to pipes\n" +
+		"instead of your terminal, so scripts that check whether they are running in a\n" +
+		"terminal, for example to prompt or to use color, behave differently.\n" +
+		"\n" +
+		"## Entry filters\n" +
+		"\n" +
+		"The `apply`, `archive`, `diff`, `dump`, `managed`, `sync`, `update`, and\n" +
+		"`verify` commands take the same flags to select which entries they operate on.\n" +
+		"\n" +
+		"`--include` and `--exclude` take a comma-separated list of entry types and can\n" +
+		"be given multiple times. An entry is included if it has any of the included\n" +
+		"types and none of the excluded types. By default, all types are included and\n" +
+		"none are excluded. Entries can have more than one type, for example an\n" +
+		"encrypted file has the types `files` and `encrypted`. The types are:\n" +
+		"\n" +
+		"| Type        | Abbreviation | Entries                                                          |\n" +
+		"| ----------- | ------------ | ---------------------------------------------------------------- |\n" +
+		"| `all`       |              | All entries                                                      |\n" +
+		"| `dirs`      | `d`          | Directories                                                      |\n" +
+		"| `encrypted` |              | Encrypted files                                                  |\n" +
+		"| `files`     | `f`          | Files                                                            |\n" +
+		"| `none`      |              | No entries                                                       |\n" +
+		"| `removes`   |              | Targets removed by `.chezmoiremove` or from `exact_` directories |\n" +
+		"| `scripts`   |              | Scripts                                                          |\n" +
+		"| `symlinks`  | `s`          | Symlinks                                                         |\n" +
+		"| `templates` |              | Templates, including templated scripts and symlinks              |\n" +
+		"\n" +
+		"`--glob` *pattern* only includes targets whose name, or the name of one of\n" +
+		"their parent directories, matches *pattern*. Target names are relative to the\n" +
+		"destination directory and patterns can contain `**` to match any number of\n" +
+		"directories. `--glob` can be given multiple times to include targets matching\n" +
+		"any of the patterns.\n" +
+		"\n" +
+		"The parent directories of included entries are always created if they do not\n" +
+		"exist. Targets ignored by `.chezmoiignore` are never included.\n" +
+		"\n" +
+		"    chezmoi apply --exclude=scripts\n" +
+		"    chezmoi diff --include=templates --glob '.config/**'\n" +
+		"    chezmoi verify --exclude=encrypted\n" +
+		"\n" +
+		"## Source state trust\n" +
+		"\n" +
+		"Scripts, triggers, and template functions that run commands or read secrets,\n" +
+		"like `secret`, `pass`, and `keyring`, can run arbitrary commands. Before `chezmoi\n" +
+		"init` or any command that applies the source state uses a source directory that\n" +
+		"is a clone of a remote repository for the first time, chezmoi lists everything\n" +
+		"in the source state that can run commands and asks you to approve it. The\n" +
+		"approval is recorded in chezmoi's persistent state for the remote URL and\n" +
+		"commit, so you are asked again whenever the source state changes to a new\n" +
+		"commit. For VCSes defined in `sourceVCS.backends`, chezmoi cannot query the\n" +
+		"remote, so the approval is recorded for the source directory and a hash of its\n" +
+		"contents instead, and you are asked again whenever the contents change. If\n" +
+		"chezmoi cannot determine the remote or commit, for example because the VCS\n" +
+		"command fails, then the source state is not trusted.\n" +
+		"\n" +
+		"Dry runs, including `chezmoi diff` and `chezmoi verify`, never ask for approval\n" +
+		"and fail if the source state has not been approved. The same applies to\n" +
+		"commands that render the target state without applying it, namely `archive`,\n" +
+		"`cat`, `dump`, `execute-template`, and `test`, as template functions that run\n" +
+		"commands are executed when templates are rendered. All other commands, for\n" +
+		"example `managed` and `source-path`, make template functions that run commands\n" +
+		"return an error until the source state has been approved. Source states without\n" +
+		"any scripts or commands do not need approval. Source directories that are not in\n" +
+		"a repository, or whose repository has no remote, are always trusted.\n" +
+		"\n" +
+		"To apply a source state without approving it, use the `--no-scripts` flag.\n" +
+		"\n" +
 		"## Template execution\n" +
 		"\n" +
 		"chezmoi executes templates using\n" +
@@ -2492,6 +3500,29 @@ func init() {
 		"For a full list of options, see\n" +
 		"[`Template.Option`](https://pkg.go.dev/text/template?tab=doc#Template.Option).\n" +
 		"\n" +
+		"### Template directives\n" +
+		"\n" +
+		"The delimiters and missing key behavior can be set for an individual template,\n" +
+		"including scripts, symlinks, and templates in `.chezmoitemplates`, with a\n" +
+		"directive. A directive is any line containing `chezmoi:template:` followed by\n" +
+		"*key*`=`*value* pairs, for example in a comment. Values containing spaces can\n" +
+		"be double quoted. Lines containing directives are removed from the template\n" +
+		"before it is parsed. The supported keys are:\n" +
+		"\n" +
+		"| Key               | Description                                                             |\n" +
+		"| ----------------- | ----------------------------------------------------------------------- |\n" +
+		"| `left-delimiter`  | The left action delimiter, default `{{`                                 |\n" +
+		"| `right-delimiter` | The right action delimiter, default `}}`                                |\n" +
+		"| `missing-key`     | The behavior for missing keys: `default`, `invalid`, `zero`, or `error` |\n" +
+		"\n" +
+		"#### Template directive examples\n" +
+		"\n" +
+		"    # chezmoi:template:left-delimiter=[[ right-delimiter=]]\n" +
+		"    image: \"{{ .Values.image }}\"\n" +
+		"    email: [[ .email ]]\n" +
+		"\n" +
+		"    <!-- chezmoi:template:missing-key=zero -->\n" +
+		"\n" +
 		"## Template variables\n" +
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
@@ -2513,6 +3544,18 @@ func init() {
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
+		"The `archive`, `cat`, `data`, `dump`, and `execute-template` commands can\n" +
+		"render the target state as if on a different machine by overriding the template\n" +
+		"data, including the automatically populated `.chezmoi` variables. The\n" +
+		"`--data-file` *filename* flag reads data from *filename*, which must be in\n" +
+		"JSON, TOML, or YAML format as determined by its extension, and the\n" +
+		"`--override-data` *key*`=`*value* flag, which can be given multiple times, sets\n" +
+		"a single value. *key* is a dot-separated path, e.g. `chezmoi.hostname`, and\n" +
+		"*value* is always a string. To set a value of another type, use *key*`:=`*json*,\n" +
+		"e.g. `--override-data personal:=false` or `--override-data 'tags:=[\"ci\"]'`. Data\n" +
+		"files are merged recursively over the template data, and `--override-data`\n" +
+		"values are applied last. The destination directory is not read or modified.\n" +
+		"\n" +
 		"## Template functions\n" +
 		"\n" +
 		"All standard [`text/template`](https://pkg.go.dev/text/template) and [text\n" +
@@ -2545,6 +3588,20 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### `includeTemplate` *name* [*data*]\n" +
+		"\n" +
+		"`includeTemplate` returns the result of executing the template *name* from the\n" +
+		"[`.chezmoitemplates`](#chezmoitemplates) directory with *data*. If *data* is\n" +
+		"not given then the template is executed with the template data. Unlike the\n" +
+		"`template` action, the result can be used in a pipeline. Templates can include\n" +
+		"themselves recursively, but it is an error if calls to `includeTemplate` are\n" +
+		"nested more than 100 deep.\n" +
+		"\n" +
+		"#### `includeTemplate` examples\n" +
+		"\n" +
+		"    {{ includeTemplate \"greeting\" (dict \"name\" \"world\") }}\n" +
+		"    {{ includeTemplate \"header\" | indent 4 }}\n" +
+		"\n" +
 		"### `keepassxc` *entry*\n" +
 		"\n" +
 		"`keepassxc` returns structured data retrieved from a\n" +
@@ -2660,11 +3717,76 @@ func init() {
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
 		"\n" +
-		"### `promptString` *prompt*\n" +
+		"### `promptBool` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptBool` prompts the user with *prompt* and returns the user's response\n" +
+		"interpreted as a boolean. The user is prompted again until they enter a valid\n" +
+		"boolean, e.g. `true`, `false`, `yes`, `no`, `y`, or `n`. If *default* is given\n" +
+		"and the user enters an empty response then *default* is returned. It is only\n" +
+		"available when generating the initial config file.\n" +
+		"\n" +
+		"#### `promptBool` examples\n" +
+		"\n" +
+		"    {{ $personal := promptBool \"personal machine\" true -}}\n" +
+		"    [data]\n" +
+		"        personal = {{ $personal }}\n" +
+		"\n" +
+		"### `promptBoolOnce` *map* *path* *prompt* [*default*]\n" +
+		"\n" +
+		"`promptBoolOnce` returns the boolean value at *path* in *map* if it exists,\n" +
+		"otherwise it behaves like `promptBool`. *path* is a dot-separated list of keys.\n" +
+		"The existing config file's data is available as `.` when generating the config\n" +
+		"file, so previous answers are reused. It is only available when generating the\n" +
+		"initial config file.\n" +
+		"\n" +
+		"#### `promptBoolOnce` examples\n" +
+		"\n" +
+		"    {{ $personal := promptBoolOnce . \"personal\" \"personal machine\" -}}\n" +
+		"\n" +
+		"### `promptChoice` *prompt* *choices* [*default*]\n" +
+		"\n" +
+		"`promptChoice` prompts the user with *prompt* and *choices* and returns the\n" +
+		"user's response. The user is prompted again until they enter one of *choices*.\n" +
+		"If *default* is given and the user enters an empty response then *default* is\n" +
+		"returned. It is an error if *choices* is empty. It is only available when\n" +
+		"generating the initial config file.\n" +
 		"\n" +
-		"`promptString` takes a single argument is a string prompted to the user, and the\n" +
-		"return value is the user's response to that prompt with all leading and trailing\n" +
-		"space stripped. It is only available when generating the initial config file.\n" +
+		"#### `promptChoice` examples\n" +
+		"\n" +
+		"    {{ $type := promptChoice \"machine type\" (list \"desktop\" \"laptop\" \"server\") \"laptop\" -}}\n" +
+		"    [data]\n" +
+		"        type = \"{{ $type }}\"\n" +
+		"\n" +
+		"### `promptChoiceOnce` *map* *path* *prompt* *choices* [*default*]\n" +
+		"\n" +
+		"`promptChoiceOnce` returns the string value at *path* in *map* if it exists and\n" +
+		"is one of *choices*, otherwise it behaves like `promptChoice`. It is only available when generating\n" +
+		"the initial config file.\n" +
+		"\n" +
+		"### `promptInt` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptInt` prompts the user with *prompt* and returns the user's response\n" +
+		"interpreted as an integer. The user is prompted again until they enter a valid\n" +
+		"integer. If *default* is given and the user enters an empty response then\n" +
+		"*default* is returned. It is only available when generating the initial config\n" +
+		"file.\n" +
+		"\n" +
+		"#### `promptInt` examples\n" +
+		"\n" +
+		"    {{ $monitors := promptInt \"number of monitors\" 1 -}}\n" +
+		"\n" +
+		"### `promptIntOnce` *map* *path* *prompt* [*default*]\n" +
+		"\n" +
+		"`promptIntOnce` returns the integer value at *path* in *map* if it exists,\n" +
+		"otherwise it behaves like `promptInt`. It is only available when generating the\n" +
+		"initial config file.\n" +
+		"\n" +
+		"### `promptString` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptString` prompts the user with *prompt* and returns the user's response\n" +
+		"with all leading and trailing space stripped. If *default* is given and the\n" +
+		"user enters an empty response then *default* is returned. It is only available\n" +
+		"when generating the initial config file.\n" +
 		"\n" +
 		"#### `promptString` examples\n" +
 		"\n" +
@@ -2672,6 +3794,18 @@ func init() {
 		"    [data]\n" +
 		"        email = \"{{ $email }}\"\n" +
 		"\n" +
+		"### `promptStringOnce` *map* *path* *prompt* [*default*]\n" +
+		"\n" +
+		"`promptStringOnce` returns the string value at *path* in *map* if it exists,\n" +
+		"otherwise it behaves like `promptString`. It is only available when generating\n" +
+		"the initial config file.\n" +
+		"\n" +
+		"#### `promptStringOnce` examples\n" +
+		"\n" +
+		"    {{ $email := promptStringOnce . \"email\" \"email\" -}}\n" +
+		"    [data]\n" +
+		"        email = \"{{ $email }}\"\n" +
+		"\n" +
 		"### `secret` [*args*]\n" +
 		"\n" +
 		"`secret` returns the output of the generic secret command defined by the\n" +
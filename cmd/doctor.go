@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
@@ -37,6 +38,8 @@ type doctorCheck interface {
 	Skip() bool
 }
 
+type doctorBuiltinVCSCheck struct{}
+
 type doctorCheckResult struct {
 	ok     bool
 	prefix string
@@ -72,12 +75,22 @@ type doctorFileCheck struct {
 
 type doctorRuntimeCheck struct{}
 
+type doctorSourceStatusCheck struct {
+	status *sourceStatus
+}
+
 type doctorSuspiciousFilesCheck struct {
 	path      string
 	filenames map[string]bool
 	found     []string
 }
 
+type doctorVerifySignaturesCheck struct {
+	verifySignatures bool
+	verifyAllCommits bool
+	keysConfigured   bool
+}
+
 type doctorVersionCheck struct{}
 
 var gpgBinaryCheck = &doctorBinaryCheck{
@@ -95,7 +108,9 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 	shell, _ := shell.CurrentUserShell()
 
 	var vcsCommandCheck doctorCheck
-	if vcs, err := c.getVCS(); err == nil {
+	if c.SourceVCS.Command == builtinVCSCommand {
+		vcsCommandCheck = doctorBuiltinVCSCheck{}
+	} else if vcs, err := c.getVCS(); err == nil {
 		vcsCommandCheck = &doctorBinaryCheck{
 			name:          "source VCS command",
 			binaryName:    c.SourceVCS.Command,
@@ -109,6 +124,10 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// The source status is only reported if it can be determined, for
+	// example if the source directory is a git repository.
+	sourceStatus, _ := c.getSourceStatus()
+
 	editorName, _ := c.getEditor()
 	editorCheck := &doctorBinaryCheck{
 		name:        "editor",
@@ -116,8 +135,7 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		mustSucceed: true,
 	}
 
-	allOK := true
-	for _, dc := range []doctorCheck{
+	dcs := []doctorCheck{
 		&doctorVersionCheck{},
 		&doctorRuntimeCheck{},
 		&doctorDirectoryCheck{
@@ -131,6 +149,9 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 				".chezmoignore": true,
 			},
 		},
+		&doctorSourceStatusCheck{
+			status: sourceStatus,
+		},
 		&doctorDirectoryCheck{
 			name: "destination directory",
 			path: c.DestDir,
@@ -203,7 +224,47 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			name:       "generic secret CLI",
 			binaryName: c.GenericSecret.Command,
 		},
-	} {
+		&doctorVerifySignaturesCheck{
+			verifySignatures: c.Update.VerifySignatures,
+			verifyAllCommits: c.Update.VerifyAllCommits,
+			keysConfigured:   c.Update.AllowedSignersFile != "" || c.Update.KeyringFile != "",
+		},
+		&doctorFileCheck{
+			name:        "allowed signers file",
+			path:        c.Update.AllowedSignersFile,
+			canSkip:     true,
+			mustSucceed: true,
+		},
+		&doctorFileCheck{
+			name:        "signature keyring file",
+			path:        c.Update.KeyringFile,
+			canSkip:     true,
+			mustSucceed: true,
+		},
+	}
+	if c.Update.AllowedSignersFile != "" {
+		dcs = append(dcs, &doctorBinaryCheck{
+			name:        "SSH signature verification command",
+			binaryName:  "ssh-keygen",
+			mustSucceed: true,
+		})
+	}
+
+	exts := make([]string, 0, len(c.Interpreters))
+	for ext := range c.Interpreters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		dcs = append(dcs, &doctorBinaryCheck{
+			name:        "interpreter for ." + ext + " scripts",
+			binaryName:  c.Interpreters[ext].Command,
+			mustSucceed: true,
+		})
+	}
+
+	allOK := true
+	for _, dc := range dcs {
 		if dc.Skip() {
 			continue
 		}
@@ -306,6 +367,26 @@ func (c *doctorBinaryCheck) getVersionFromOutput(output []byte) (*semver.Version
 	return semver.NewVersion(string(m[1]))
 }
 
+func (doctorBuiltinVCSCheck) Check() (bool, error) {
+	return true, nil
+}
+
+func (doctorBuiltinVCSCheck) Enabled() bool {
+	return true
+}
+
+func (doctorBuiltinVCSCheck) MustSucceed() bool {
+	return false
+}
+
+func (doctorBuiltinVCSCheck) Result() string {
+	return "builtin git (sourceVCS.command)"
+}
+
+func (doctorBuiltinVCSCheck) Skip() bool {
+	return false
+}
+
 func (c *doctorDirectoryCheck) Check() (bool, error) {
 	c.info, c.err = os.Stat(c.path)
 	if c.err != nil && os.IsNotExist(c.err) {
@@ -395,6 +476,26 @@ func (doctorRuntimeCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorSourceStatusCheck) Check() (bool, error) {
+	return c.status.InSync(), nil
+}
+
+func (c *doctorSourceStatusCheck) Enabled() bool {
+	return true
+}
+
+func (c *doctorSourceStatusCheck) MustSucceed() bool {
+	return false
+}
+
+func (c *doctorSourceStatusCheck) Result() string {
+	return "source status: " + c.status.String()
+}
+
+func (c *doctorSourceStatusCheck) Skip() bool {
+	return c.status == nil
+}
+
 func (c *doctorSuspiciousFilesCheck) Check() (bool, error) {
 	if err := filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -429,6 +530,35 @@ func (c *doctorSuspiciousFilesCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorVerifySignaturesCheck) Check() (bool, error) {
+	return !c.verifySignatures || c.keysConfigured, nil
+}
+
+func (c *doctorVerifySignaturesCheck) Enabled() bool {
+	return true
+}
+
+func (c *doctorVerifySignaturesCheck) MustSucceed() bool {
+	return false
+}
+
+func (c *doctorVerifySignaturesCheck) Result() string {
+	switch {
+	case !c.verifySignatures:
+		return "disabled (update.verifySignatures)"
+	case !c.keysConfigured:
+		return "enabled, but any key trusted by git is accepted (update.allowedSignersFile, update.keyringFile)"
+	case c.verifyAllCommits:
+		return "enabled, verifying all new commits (update.verifySignatures)"
+	default:
+		return "enabled, verifying HEAD (update.verifySignatures)"
+	}
+}
+
+func (c *doctorVerifySignaturesCheck) Skip() bool {
+	return false
+}
+
 func (doctorVersionCheck) Check() (bool, error) {
 	if VersionStr == "" || Commit == "" || Date == "" {
 		return false, nil
@@ -30,6 +30,9 @@ func init() {
 	persistentFlags.BoolVarP(&config.dump.recursive, "recursive", "r", true, "recursive")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(dumpCmd, 1)
+	addOverrideDataFlags(dumpCmd)
+	addSourceRefFlag(dumpCmd)
+	addEntryFilterFlags(dumpCmd)
 }
 
 func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
@@ -37,6 +40,9 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.dump.format)
 	}
+	if err := c.ensureRenderTrusted(); err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -54,7 +60,7 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 		}
 		var concreteValues []interface{}
 		for _, entry := range entries {
-			entryConcreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, os.FileMode(c.Umask), c.dump.recursive)
+			entryConcreteValue, err := entry.ConcreteValue(ts.Ignore, ts.SourceDir, os.FileMode(c.Umask), c.dump.recursive)
 			if err != nil {
 				return err
 			}
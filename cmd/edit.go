@@ -154,11 +154,18 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	scriptEnv, cleanup, err := c.getScriptEnv()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
 	readOnlyFS := vfs.NewReadOnlyFS(c.fs)
 	applyOptions := chezmoi.ApplyOptions{
 		DestDir:           ts.DestDir,
 		DryRun:            c.DryRun,
-		Ignore:            ts.TargetIgnore.Match,
+		Ignore:            ts.Ignore,
+		ScriptEnv:         scriptEnv,
 		ScriptStateBucket: c.scriptStateBucket,
 		Stdout:            c.Stdout,
 		Umask:             ts.Umask,
@@ -11,6 +11,9 @@ import (
 type executeTemplateCmdConfig struct {
 	init         bool
 	output       string
+	promptBool   map[string]string
+	promptChoice map[string]string
+	promptInt    map[string]int
 	promptString map[string]string
 }
 
@@ -29,19 +32,29 @@ func init() {
 	persistentFlags := executeTemplateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.executeTemplate.init, "init", "i", false, "simulate chezmoi init")
 	persistentFlags.StringVarP(&config.executeTemplate.output, "output", "o", "", "output filename")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptBool, "promptBool", nil, "simulate promptBool")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptChoice, "promptChoice", nil, "simulate promptChoice")
+	persistentFlags.StringToIntVar(&config.executeTemplate.promptInt, "promptInt", nil, "simulate promptInt")
 	persistentFlags.StringToStringVarP(&config.executeTemplate.promptString, "promptString", "p", nil, "simulate promptString")
+	addOverrideDataFlags(executeTemplateCmd)
 }
 
 func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error {
 	if c.executeTemplate.init {
-		c.templateFuncs["promptString"] = func(prompt string) string {
-			if value, ok := c.executeTemplate.promptString[prompt]; ok {
-				return value
-			}
-			return prompt
+		p := &prompter{
+			bools:   c.executeTemplate.promptBool,
+			choices: c.executeTemplate.promptChoice,
+			ints:    c.executeTemplate.promptInt,
+			strings: c.executeTemplate.promptString,
+		}
+		for key, value := range p.funcMap() {
+			c.templateFuncs[key] = value
 		}
 	}
 
+	if err := c.ensureRenderTrusted(); err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
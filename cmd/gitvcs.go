@@ -42,6 +42,10 @@ func (gitVCS) StatusArgs() []string {
 	return []string{"status", "--porcelain=v2"}
 }
 
+func (gitVCS) UpdateSubmodulesArgs() []string {
+	return []string{"submodule", "update", "--init"}
+}
+
 func (gitVCS) VersionArgs() []string {
 	return []string{"version"}
 }
@@ -55,11 +55,51 @@ var helps = map[string]help{
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary. If\n" +
-			"  no targets are specified, the state of all targets are ensured.",
+			"  no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+			"  instead of from the source directory. The source directory is not changed, so\n" +
+			"  this can be used to roll back to an earlier version of your dotfiles.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi apply\n" +
 			"  chezmoi apply --dry-run --verbose\n" +
-			"  chezmoi apply ~/.bashrc",
+			"  chezmoi apply ~/.bashrc\n" +
+			"  chezmoi apply --source-ref HEAD~1\n" +
+			"  chezmoi apply --exclude=scripts",
+	},
+	"apply-patch": {
+		long: "" +
+			"Description:\n" +
+			"  Apply the git patch *patch* to the destination directory. If *patch* is not\n" +
+			"  given or is `-`, the patch is read from the standard input. Patches written by\n" +
+			"  `chezmoi diff --format=git` can be applied, so changes can be reviewed and then\n" +
+			"  applied on a machine without access to your source repo. The patch must apply\n" +
+			"  exactly: each file's contents must match the index line and the hunk context,\n" +
+			"  otherwise nothing is changed. Binary patches must be literal, as written by\n" +
+			"  `chezmoi diff` and `git diff --binary`, and copies are not supported. Git\n" +
+			"  patches only record whether a file is executable, so existing files keep their\n" +
+			"  permissions, apart from the executable bits if the patch changes the file's\n" +
+			"  mode.\n" +
+			"\n" +
+			"  `apply-patch` examples\n" +
+			"\n" +
+			"    chezmoi diff --format=git > changes.patch\n" +
+			"    chezmoi apply-patch changes.patch\n" +
+			"    chezmoi apply-patch --dry-run --verbose < changes.patch",
 	},
 	"archive": {
 		long: "" +
@@ -69,19 +109,66 @@ var helps = map[string]help{
 			"\n" +
 			"  `--output`, `-o` *filename*\n" +
 			"\n" +
-			"  Write the output to *filename* instead of stdout.",
+			"  Write the output to *filename* instead of stdout.\n" +
+			"\n" +
+			"  `--data-file` *filename*\n" +
+			"\n" +
+			"  Override the template data with the data in *filename*. See template\n" +
+			"  variables.\n" +
+			"\n" +
+			"  `--override-data` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data at *key* with the string *value*, or, if given as\n" +
+			"  *key*`:=`*json*, with the value of *json*. See template variables.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+			"  instead of from the source directory.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi archive | tar tvf -\n" +
-			"  chezmoi archive --output=dotfiles.tar",
+			"  chezmoi archive --output=dotfiles.tar\n" +
+			"  chezmoi archive --data-file=laptop.yaml | tar tvf -\n" +
+			"  chezmoi archive --source-ref v1.0.0 --output=dotfiles.tar\n" +
+			"  chezmoi archive --glob '.config/**' --output=config.tar",
 	},
 	"cat": {
 		long: "" +
 			"Description:\n" +
 			"  Write the target state of *targets*  to stdout. *targets* must be files or\n" +
 			"  symlinks. For files, the target file contents are written. For symlinks, the\n" +
-			"  target target is written.",
+			"  target target is written.\n" +
+			"\n" +
+			"  `--data-file` *filename*\n" +
+			"\n" +
+			"  Override the template data with the data in *filename*. See template\n" +
+			"  variables.\n" +
+			"\n" +
+			"  `--override-data` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data at *key* with the string *value*, or, if given as\n" +
+			"  *key*`:=`*json*, with the value of *json*. See template variables.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+			"  instead of from the source directory.",
 		example: "" +
-			"  chezmoi cat ~/.bashrc",
+			"  chezmoi cat ~/.bashrc\n" +
+			"  chezmoi cat --override-data chezmoi.hostname=ci-runner ~/.gitconfig\n" +
+			"  chezmoi cat --source-ref HEAD~1 ~/.bashrc",
 	},
 	"cd": {
 		long: "" +
@@ -89,7 +176,8 @@ var helps = map[string]help{
 			"  Launch a shell in the source directory. chezmoi will launch the command set by\n" +
 			"  the `cd.command` configuration variable with any extra arguments specified by\n" +
 			"  `cd.args`. If this is not set, chezmoi will attempt to detect your shell and\n" +
-			"  will finally fall back to an OS-specific default.",
+			"  will finally fall back to an OS-specific default. The shell is run with the\n" +
+			"  same environment variables as scripts.",
 		example: "" +
 			"  chezmoi cd",
 	},
@@ -140,7 +228,17 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the computed template data in the given format. The accepted formats are\n" +
-			"  `json` (JSON), `toml` (TOML), and `yaml` (YAML).",
+			"  `json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+			"\n" +
+			"  `--data-file` *filename*\n" +
+			"\n" +
+			"  Override the template data with the data in *filename*. See template\n" +
+			"  variables.\n" +
+			"\n" +
+			"  `--override-data` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data at *key* with the string *value*, or, if given as\n" +
+			"  *key*`:=`*json*, with the value of *json*. See template variables.",
 		example: "" +
 			"  chezmoi data\n" +
 			"  chezmoi data --format=yaml",
@@ -154,6 +252,33 @@ var helps = map[string]help{
 			"  If a `diff.pager` command is set in the configuration file then the output\n" +
 			"  will be piped into it.\n" +
 			"\n" +
+			"  Any triggers that would be run are listed after the diff.\n" +
+			"\n" +
+			"  If a `diff.command` is set in the configuration file then it is used instead\n" +
+			"  of the builtin diff, and `--format` is ignored. The command is run once for each\n" +
+			"  changed file with the arguments in `diff.args`, which are templates in which\n" +
+			"  `{{ .Destination }}` is a temporary copy of the destination file and `{{\n" +
+			"  .Target }}` is a temporary file holding the target contents. The contents of a\n" +
+			"  symlink are its target. If a file does not exist on one side then `/dev/null`\n" +
+			"  is used instead. `diff.args` defaults to `[\"{{ .Destination }}\", \"{{ .Target\n" +
+			"  }}\"]`. The exit status of the command is ignored. The command is run with your\n" +
+			"  terminal, so interactive tools like `vimdiff` work, and its output is only\n" +
+			"  piped into `diff.pager` if `diff.commandPager` is `true`, which is useful for\n" +
+			"  tools that only print a diff. For example, to use delta\n" +
+			"  https://github.com/dandavison/delta with `less` as the pager:\n" +
+			"\n" +
+			"    [diff]\n" +
+			"        command = \"delta\"\n" +
+			"        commandPager = true\n" +
+			"        pager = \"less -R\"\n" +
+			"\n" +
+			"  `--dir-diff`\n" +
+			"\n" +
+			"  Run `diff.command` once, with `{{ .Destination }}` and `{{ .Target }}` set to\n" +
+			"  temporary directories containing all changed files, instead of once per file.\n" +
+			"  This can also be set with the `diff.dirDiff` variable in the configuration\n" +
+			"  file.\n" +
+			"\n" +
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the diff in *format*. The format can be set with the `diff.format`\n" +
@@ -170,13 +295,47 @@ var helps = map[string]help{
 			"  version 2.0.0 of chezmoi, `git` format diffs will become the default and\n" +
 			"  include scripts and the `chezmoi` format will be removed.\n" +
 			"\n" +
+			"  The diff includes full blob hashes and binary patches, so it can be applied\n" +
+			"  with `git apply` or `chezmoi apply-patch`. As git does not track directories,\n" +
+			"  they are not included, and changes that git cannot record, such as a change\n" +
+			"  from mode `0644` to `0600`, are omitted.\n" +
+			"\n" +
 			"  `--no-pager`\n" +
 			"\n" +
-			"  Do not use the pager.",
+			"  Do not use the pager.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+			"  instead of from the source directory.\n" +
+			"\n" +
+			"  `--between` *revision1* *revision2*\n" +
+			"\n" +
+			"  Instead of comparing with the destination state, print the difference between\n" +
+			"  the target states of two git revisions of the source directory, for example to\n" +
+			"  see what your dotfiles looked like last week. The arguments are the revisions\n" +
+			"  rather than targets, and the diff is always a unified diff of the target\n" +
+			"  contents.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi diff\n" +
 			"  chezmoi diff ~/.bashrc\n" +
-			"  chezmoi diff --format=git",
+			"  chezmoi diff --format=git\n" +
+			"  chezmoi diff --dir-diff\n" +
+			"  chezmoi diff --source-ref HEAD~1\n" +
+			"  chezmoi diff --between 'HEAD@{1.week.ago}' HEAD\n" +
+			"  chezmoi diff --glob '.config/**'",
 	},
 	"docs": {
 		long: "" +
@@ -204,10 +363,40 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the target state in the given format. The accepted formats are `json`\n" +
-			"  (JSON) and `yaml` (YAML).",
+			"  (JSON) and `yaml` (YAML).\n" +
+			"\n" +
+			"  `--data-file` *filename*\n" +
+			"\n" +
+			"  Override the template data with the data in *filename*. See template\n" +
+			"  variables.\n" +
+			"\n" +
+			"  `--override-data` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data at *key* with the string *value*, or, if given as\n" +
+			"  *key*`:=`*json*, with the value of *json*. See template variables.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Read the source state from the git *revision*, for example `HEAD~1` or a tag,\n" +
+			"  instead of from the source directory.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi dump ~/.bashrc\n" +
-			"  chezmoi dump --format=yaml",
+			"  chezmoi dump --format=yaml\n" +
+			"  chezmoi dump --data-file=laptop.yaml --override-data chezmoi.os=darwin\n" +
+			"  chezmoi dump --source-ref HEAD~1 ~/.bashrc\n" +
+			"  chezmoi dump --include=templates",
 	},
 	"edit": {
 		long: "" +
@@ -258,12 +447,46 @@ var helps = map[string]help{
 			"\n" +
 			"  Write the output to *filename* instead of stdout.\n" +
 			"\n" +
+			"  `--promptBool` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptBool` function with a function that returns values from\n" +
+			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptBool` is called with a *prompt* that does not match any of *pairs*,\n" +
+			"  then it returns its default value if given, or `false` otherwise.\n" +
+			"\n" +
+			"  `--promptChoice` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptChoice` function with a function that returns values from\n" +
+			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptChoice` is called with a *prompt* that does not match any of *pairs*,\n" +
+			"  then it returns its default value if given, or the first choice otherwise.\n" +
+			"\n" +
+			"  `--promptInt` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptInt` function with a function that returns values from\n" +
+			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptInt` is called with a *prompt* that does not match any of *pairs*, then\n" +
+			"  it returns its default value if given, or `0` otherwise.\n" +
+			"\n" +
 			"  `--promptString`, `-p` *pairs*\n" +
 			"\n" +
 			"  Simulate the `promptString` function with a function that returns values from\n" +
 			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
 			"  `promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
-			"  then it returns *prompt* unchanged.\n" +
+			"  then it returns its default value if given, or *prompt* unchanged otherwise.\n" +
+			"\n" +
+			"  The `prompt*Once` functions are simulated in the same way, returning the\n" +
+			"  existing value from the template data if present.\n" +
+			"\n" +
+			"  `--data-file` *filename*\n" +
+			"\n" +
+			"  Override the template data with the data in *filename*. See template\n" +
+			"  variables.\n" +
+			"\n" +
+			"  `--override-data` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data at *key* with the string *value*, or, if given as\n" +
+			"  *key*`:=`*json*, with the value of *json*. See template variables.\n" +
 			"\n" +
 			"  `execute-template` examples\n" +
 			"\n" +
@@ -303,6 +526,37 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi hg -- pull --rebase --update",
 	},
+	"history": {
+		long: "" +
+			"Description:\n" +
+			"  Print the changes to *target* in each commit of the git repository containing\n" +
+			"  the source directory, newest first. At each commit, *target* is mapped to its\n" +
+			"  source file, following renames made by `chezmoi chattr` and similar, and is\n" +
+			"  rendered with the current template data, so the diffs show how the target\n" +
+			"  itself changed. Commits that do not change *target* are skipped, as are the\n" +
+			"  second and later parents of merges. Template functions that run commands are\n" +
+			"  disabled for commits that you have not trusted.\n" +
+			"\n" +
+			"  `--max-count` *count*\n" +
+			"\n" +
+			"  Print at most *count* commits.\n" +
+			"\n" +
+			"  `--no-pager`\n" +
+			"\n" +
+			"  Do not use the pager.\n" +
+			"\n" +
+			"  `--source`\n" +
+			"\n" +
+			"  Print the changes to the source file instead of the rendered target.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Start from the git *revision* instead of `HEAD`.",
+		example: "" +
+			"  chezmoi history ~/.bashrc\n" +
+			"  chezmoi history --max-count=3 ~/.gitconfig\n" +
+			"  chezmoi history --source ~/.ssh/config",
+	},
 	"import": {
 		long: "" +
 			"Description:\n" +
@@ -342,10 +596,115 @@ var helps = map[string]help{
 			"  If a file called `.chezmoi.format.tmpl` exists, where `format` is one of the\n" +
 			"  supported file formats (e.g. `json`, `toml`, or `yaml`) then a new\n" +
 			"  configuration file is created using that file as a template. Finally, if the `--\n" +
-			"  apply` flag is passed, `chezmoi apply` is run.",
+			"  apply` flag is passed, `chezmoi apply` is run.\n" +
+			"\n" +
+			"  Before the configuration file template is executed, chezmoi checks that the\n" +
+			"  source state is trusted.\n" +
+			"\n" +
+			"  If *repo* is a GitHub username, for example `user`, then it is expanded to\n" +
+			"  `https://github.com/user/dotfiles.git`. If *repo* is a GitHub username and\n" +
+			"  repo name separated by a slash, for example `user/repo`, then it is expanded\n" +
+			"  to `https://github.com/user/repo.git`.\n" +
+			"\n" +
+			"  `--apply`\n" +
+			"\n" +
+			"  Run `chezmoi apply` after checking out the repo and creating the config file.\n" +
+			"\n" +
+			"  `--branch` *branch*\n" +
+			"\n" +
+			"  Check out *branch* instead of the default branch.\n" +
+			"\n" +
+			"  `-d`, `--depth` *depth*\n" +
+			"\n" +
+			"  Clone the repo with depth *depth*, i.e. create a shallow clone. Only supported\n" +
+			"  when the source VCS is `git`.\n" +
+			"\n" +
+			"  `--one-shot`\n" +
+			"\n" +
+			"  `chezmoi init --one-shot` attempts to install your dotfiles with a single command\n" +
+			"  and then remove all traces of chezmoi from the system. This is useful for\n" +
+			"  setting up your dotfiles on transitory machines like containers and CI\n" +
+			"  runners. It is equivalent to `--apply --depth=1` followed by `chezmoi purge --\n" +
+			"  force`.\n" +
+			"\n" +
+			"  `--promptBool` *pairs*\n" +
+			"\n" +
+			"  Populate the `promptBool` template function with values from *pairs*. *pairs*\n" +
+			"  is a comma-separated list of *prompt*`=`*value* pairs. If `promptBool` is\n" +
+			"  called with a *prompt* that does not match any of *pairs*, then it prompts the\n" +
+			"  user for a value.\n" +
+			"\n" +
+			"  `--promptChoice` *pairs*\n" +
+			"\n" +
+			"  Populate the `promptChoice` template function with values from *pairs*.\n" +
+			"  *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptChoice` is called with a *prompt* that does not match any of *pairs*,\n" +
+			"  then it prompts the user for a value.\n" +
+			"\n" +
+			"  `--promptDefaults`\n" +
+			"\n" +
+			"  Make all `prompt*` template function calls with a default value return that\n" +
+			"  default value instead of prompting.\n" +
+			"\n" +
+			"  `--promptInt` *pairs*\n" +
+			"\n" +
+			"  Populate the `promptInt` template function with values from *pairs*. *pairs*\n" +
+			"  is a comma-separated list of *prompt*`=`*value* pairs. If `promptInt` is called\n" +
+			"  with a *prompt* that does not match any of *pairs*, then it prompts the user\n" +
+			"  for a value.\n" +
+			"\n" +
+			"  `--promptString` *pairs*\n" +
+			"\n" +
+			"  Populate the `promptString` template function with values from *pairs*.\n" +
+			"  *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
+			"  `promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
+			"  then it prompts the user for a value.\n" +
+			"\n" +
+			"  `--regenerate-config`\n" +
+			"\n" +
+			"  Regenerate the config file from the config template without checking out the\n" +
+			"  repo. The data in the existing config file is available to the config\n" +
+			"  template, so the `prompt*Once` template functions reuse previous answers and\n" +
+			"  only prompt for new values.\n" +
+			"\n" +
+			"  `--ssh`\n" +
+			"\n" +
+			"  Use `git@github.com:user/repo.git` instead of\n" +
+			"  `https://github.com/user/repo.git` when expanding a GitHub username or\n" +
+			"  username and repo name.",
 		example: "" +
+			"  chezmoi init user\n" +
+			"  chezmoi init user --apply\n" +
+			"  chezmoi init user --apply --ssh\n" +
+			"  chezmoi init user/dots --branch main --depth 1\n" +
+			"  chezmoi init user --one-shot\n" +
+			"  chezmoi init --regenerate-config\n" +
 			"  chezmoi init https://github.com/user/dotfiles.git\n" +
-			"  chezmoi init https://github.com/user/dotfiles.git --apply",
+			"  chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+			"  chezmoi init https://github.com/user/dotfiles.git --promptDefaults --promptString\n" +
+			"email=john@home.org",
+	},
+	"lint": {
+		long: "" +
+			"Description:\n" +
+			"  Check the source state for problems without applying it. `lint` parses every\n" +
+			"  template, `.chezmoiignore`, `.chezmoiremove`, and `.chezmoitemplates` file\n" +
+			"  with the same template functions used by `apply`, and reports:\n" +
+			"\n" +
+			"  • Template syntax errors and calls to undefined template functions.\n" +
+			"  • References to template data, for example `.email`, that do not exist in the\n" +
+			"  template data of this machine or of any .chezmoitests\n" +
+			"  test case.\n" +
+			"  • References to templates that are not defined.\n" +
+			"  • Source names containing prefixes in the wrong order or prefixes that are not\n" +
+			"  valid for their type, which would otherwise silently become part of the\n" +
+			"  target name, for example `executable_private_dot_foo` or `exact_file`.\n" +
+			"\n" +
+			"  Each problem is printed as a `file:line:col: message` diagnostic and chezmoi\n" +
+			"  exits with a non-zero exit status if any problems are found. Encrypted files\n" +
+			"  are not checked.",
+		example: "" +
+			"  chezmoi lint",
 	},
 	"manage": {
 		long: "" +
@@ -359,16 +718,24 @@ var helps = map[string]help{
 			"\n" +
 			"  `-i`, `--include` *types*\n" +
 			"\n" +
-			"  Only list entries of type *types*. *types* is a comma-separated list of types\n" +
-			"  of entry to include. Valid types are `dirs`, `files`, and `symlinks` which can\n" +
-			"  be abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will\n" +
-			"  list entries of all types.",
+			"  Only list entries of type *types*. See entry filters. By default, `managed`\n" +
+			"  lists directories, files, and symlinks.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Do not list entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only list targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi managed\n" +
 			"  chezmoi managed --include=files\n" +
 			"  chezmoi managed --include=files,symlinks\n" +
 			"  chezmoi managed -i d\n" +
-			"  chezmoi managed -i d,f",
+			"  chezmoi managed -i d,f\n" +
+			"  chezmoi managed --include=scripts\n" +
+			"  chezmoi managed --exclude=encrypted --glob '.config/**'",
 	},
 	"merge": {
 		long: "" +
@@ -455,6 +822,94 @@ var helps = map[string]help{
 			"    chezmoi source-path\n" +
 			"    chezmoi source-path ~/.bashrc",
 	},
+	"source-status": {
+		long: "" +
+			"Description:\n" +
+			"  Print the sync state of the source repository: the current branch and its\n" +
+			"  upstream, how many commits the branch is ahead of or behind its upstream,\n" +
+			"  whether there are uncommitted changes, and the number of stashes. The state is\n" +
+			"  one of `up-to-date`, `ahead`, `behind`, `diverged`, or `no-upstream`. The source\n" +
+			"  VCS must be `git`. The state is determined from the local repository, so run\n" +
+			"  `chezmoi source fetch` first to see new commits in the upstream.\n" +
+			"\n" +
+			"  If `update.verifySignatures` is `true`, the source status also reports whether\n" +
+			"  the signature of HEAD is `verified` or `unverified`, using the same keys as\n" +
+			"  chezmoi update.\n" +
+			"\n" +
+			"  `chezmoi doctor` includes the source status, and, if `sourceVCS.warnOnApply`\n" +
+			"  is `true`, `chezmoi apply` prints a warning if the source has uncommitted\n" +
+			"  changes or is ahead of or behind its upstream.\n" +
+			"\n" +
+			"  `-f`, `--format` `text`|`json`|`toml`|`yaml`\n" +
+			"\n" +
+			"  Print the source status in the given format. The default is `text`. The `json`\n" +
+			"  format is suitable for use in shell prompts.\n" +
+			"\n" +
+			"  `source-status` examples\n" +
+			"\n" +
+			"    chezmoi source-status\n" +
+			"    chezmoi source-status --format=json",
+	},
+	"sync": {
+		long: "" +
+			"Description:\n" +
+			"  Synchronize the source state, the destination directory, and the remote repo\n" +
+			"  in one step. chezmoi:\n" +
+			"\n" +
+			"  1. Pulls changes from the source VCS.\n" +
+			"  2. Adds back any target that has been edited in the destination directory\n" +
+			"  since\n" +
+			"  it was last applied. If the target has also changed in the source state, or\n" +
+			"  is a template, or chezmoi does not know its contents when it was last\n" +
+			"  applied, then the merge command is run instead, as for `chezmoi merge`.\n" +
+			"  3. Commits any changes to the source directory with an automatically-generated\n" +
+			"  commit message and pushes them.\n" +
+			"  4. Applies the resulting target state.\n" +
+			"\n" +
+			"  With `--dry-run`, chezmoi prints what each step would do without changing\n" +
+			"  anything.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
+		example: "" +
+			"  chezmoi sync\n" +
+			"  chezmoi sync --dry-run\n" +
+			"  chezmoi sync --glob '.config/**'",
+	},
+	"test": {
+		long: "" +
+			"Description:\n" +
+			"  Render the target state with the data from each test case in the\n" +
+			"  `.chezmoitests` directory in the source directory and compare the result with\n" +
+			"  the case's golden files. If no cases are specified then all cases are run.\n" +
+			"  Differences are printed as unified diffs, and chezmoi exits with a non-zero\n" +
+			"  exit status if any test fails. See .chezmoitests for the layout of a test\n" +
+			"  case.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the results in the given format. The accepted formats are `text`, `tap`\n" +
+			"  (Test Anything Protocol), and `junit` (JUnit XML).\n" +
+			"\n" +
+			"  `-u`, `--update`\n" +
+			"\n" +
+			"  Update the golden files with the rendered target state instead of reporting\n" +
+			"  differences.",
+		example: "" +
+			"  chezmoi test\n" +
+			"  chezmoi test work\n" +
+			"  chezmoi test --update\n" +
+			"  chezmoi test --format=junit > report.xml",
+	},
 	"unmanage": {
 		long: "" +
 			"Description:\n" +
@@ -470,9 +925,69 @@ var helps = map[string]help{
 	"update": {
 		long: "" +
 			"Description:\n" +
-			"  Pull changes from the source VCS and apply any changes.",
+			"  Pull changes from the source VCS and apply any changes. If the source VCS is\n" +
+			"  git then any submodules are initialized and updated after pulling.\n" +
+			"\n" +
+			"  After pulling, chezmoi prints a summary of the targets that were added,\n" +
+			"  modified, or removed in the target state, the scripts that will be run, and\n" +
+			"  the new minimum version of chezmoi if `.chezmoiversion` changed.\n" +
+			"\n" +
+			"  If the source VCS is git and any step fails before chezmoi starts applying\n" +
+			"  changes, for example because the new source state contains an invalid\n" +
+			"  template, then the source directory is restored to the previous HEAD.\n" +
+			"\n" +
+			"  `-a`, `--apply`\n" +
+			"\n" +
+			"  Apply changes after pulling. This is the default, use `--apply=false` to only\n" +
+			"  pull.\n" +
+			"\n" +
+			"  `-d`, `--diff`\n" +
+			"\n" +
+			"  Print the difference between the target state before and after pulling.\n" +
+			"\n" +
+			"  `-p`, `--prompt`\n" +
+			"\n" +
+			"  Prompt before applying changes. Implies `--diff`. If you decline then the\n" +
+			"  changes remain in the source directory and can be applied later with `chezmoi\n" +
+			"  apply`.\n" +
+			"\n" +
+			"  If `update.verifySignatures` is `true` in the configuration file then, after\n" +
+			"  pulling, chezmoi runs `git verify-commit` on the new HEAD, or on every new\n" +
+			"  commit if `update.verifyAllCommits` is also `true`. If any signature is\n" +
+			"  missing or invalid then the source directory is restored to the previous HEAD\n" +
+			"  and no changes are applied. Only git is supported.\n" +
+			"\n" +
+			"  SSH signatures are checked against the `update.allowedSignersFile` file, in\n" +
+			"  the format described in `ssh-keygen(1)`. GPG signatures are checked against the\n" +
+			"  keys in the `update.keyringFile` file, which can be exported with `gpg --export`\n" +
+			"  and is imported into a temporary keyring. If only one of them is set then\n" +
+			"  signatures in the other format are always rejected. If neither is set, git's\n" +
+			"  own signature configuration is used, so a commit signed by any key in your\n" +
+			"  default GnuPG keyring or git's allowed signers file is accepted, and `chezmoi\n" +
+			"  doctor` warns about this. For example:\n" +
+			"\n" +
+			"    [update]\n" +
+			"      verifySignatures = true\n" +
+			"      allowedSignersFile = \"/home/user/.config/chezmoi/allowed_signers\"\n" +
+			"\n" +
+			"  `chezmoi doctor` reports whether signature verification is enabled.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
-			"  chezmoi update",
+			"  chezmoi update\n" +
+			"  chezmoi update --prompt\n" +
+			"  chezmoi update --diff --apply=false\n" +
+			"  chezmoi update --exclude=scripts",
 	},
 	"upgrade": {
 		long: "" +
@@ -500,9 +1015,22 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Verify that all *targets* match their target state. chezmoi exits with code 0\n" +
 			"  (success) if all targets match their target state, or 1 (failure) otherwise.\n" +
-			"  If no targets are specified then all targets are checked.",
+			"  If no targets are specified then all targets are checked.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. See entry filters.\n" +
+			"\n" +
+			"  `--glob` *pattern*\n" +
+			"\n" +
+			"  Only include targets matching *pattern*. See entry filters.",
 		example: "" +
 			"  chezmoi verify\n" +
-			"  chezmoi verify ~/.bashrc",
+			"  chezmoi verify ~/.bashrc\n" +
+			"  chezmoi verify --exclude=encrypted",
 	},
 }
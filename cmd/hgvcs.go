@@ -1,13 +1,17 @@
 package cmd
 
-import "regexp"
+import (
+	"regexp"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
 
 var hgVersionRegexp = regexp.MustCompile(`^Mercurial Distributed SCM \(version (\d+\.\d+(\.\d+)?\))`)
 
 type hgVCS struct{}
 
 func (hgVCS) AddArgs(path string) []string {
-	return nil
+	return []string{"addremove", path}
 }
 
 func (hgVCS) CloneArgs(repo, dir string) []string {
@@ -15,7 +19,7 @@ func (hgVCS) CloneArgs(repo, dir string) []string {
 }
 
 func (hgVCS) CommitArgs(message string) []string {
-	return nil
+	return []string{"commit", "--message", message}
 }
 
 func (hgVCS) InitArgs() []string {
@@ -23,7 +27,7 @@ func (hgVCS) InitArgs() []string {
 }
 
 func (hgVCS) ParseStatusOutput(output []byte) (interface{}, error) {
-	return nil, nil
+	return git.ParseHgStatus(output)
 }
 
 func (hgVCS) PullArgs() []string {
@@ -31,10 +35,14 @@ func (hgVCS) PullArgs() []string {
 }
 
 func (hgVCS) PushArgs() []string {
-	return nil
+	return []string{"push"}
 }
 
 func (hgVCS) StatusArgs() []string {
+	return []string{"status"}
+}
+
+func (hgVCS) UpdateSubmodulesArgs() []string {
 	return nil
 }
 
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type historyCmdConfig struct {
+	maxCount int
+	source   bool
+}
+
+// A targetRevision is the state of a single target at a commit.
+type targetRevision struct {
+	commit         *object.Commit
+	targetName     string
+	sourceName     string
+	snapshot       *targetSnapshot
+	sourceContents []byte
+	err            error
+}
+
+var historyCmd = &cobra.Command{
+	Use:     "history target",
+	Args:    cobra.ExactArgs(1),
+	Short:   "Print the changes to a target in each commit of the source repository",
+	Long:    mustGetLongHelp("history"),
+	Example: getExample("history"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runHistoryCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	persistentFlags := historyCmd.PersistentFlags()
+	persistentFlags.IntVar(&config.history.maxCount, "max-count", 0, "show at most this many commits")
+	persistentFlags.BoolVar(&config.Diff.NoPager, "no-pager", false, "disable pager")
+	persistentFlags.BoolVar(&config.history.source, "source", false, "print changes to the source file instead of the target")
+
+	markRemainingZshCompPositionalArgumentsAsFiles(historyCmd, 1)
+	addSourceRefFlag(historyCmd)
+}
+
+func (c *Config) runHistoryCmd(cmd *cobra.Command, args []string) error {
+	targetPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	destDir, err := filepath.Abs(c.DestDir)
+	if err != nil {
+		return err
+	}
+	targetName, err := filepath.Rel(destDir, targetPath)
+	if err != nil {
+		return err
+	}
+
+	repo, prefix, err := c.openSourceRepo()
+	if err != nil {
+		return err
+	}
+	hash, err := c.resolveSourceRevision(repo, c.getSourceRevision())
+	if err != nil {
+		return err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	return c.runWithPager(func(w io.Writer) error {
+		rev := c.getTargetRevision(commit, prefix, targetPath, targetName)
+		for count := 0; rev != nil && (c.history.maxCount <= 0 || count < c.history.maxCount); {
+			// Follow first parents only, so merges appear as a single change.
+			// A missing parent, for example in a shallow clone, ends the
+			// history.
+			var parentRev *targetRevision
+			if parent, err := rev.commit.Parent(0); err == nil {
+				parentRev = c.getTargetRevision(parent, prefix, targetPath, targetName)
+			}
+			if rev.changedFrom(parentRev) {
+				if err := c.writeTargetRevision(w, parentRev, rev); err != nil {
+					return err
+				}
+				count++
+			}
+			rev = parentRev
+		}
+		return nil
+	})
+}
+
+// getTargetRevision returns the state of the target at targetPath, with
+// targetName relative to the destination directory, at commit, rendered with
+// the current template data. Only the parts of the source state that can
+// contain the target are read.
+func (c *Config) getTargetRevision(commit *object.Commit, prefix, targetPath, targetName string) *targetRevision {
+	rev := &targetRevision{
+		commit: commit,
+	}
+
+	// Set the source ref so that whether the source state is trusted is
+	// decided for commit.
+	prevSourceRef := c.sourceRef
+	defer func() {
+		c.sourceRef = prevSourceRef
+	}()
+	c.sourceRef = commit.Hash.String()
+
+	fs, err := c.getCommitSourceFS(commit, prefix)
+	if err != nil {
+		rev.err = err
+		return rev
+	}
+	ts, err := c.getTargetStateFromFS(fs, &chezmoi.PopulateOptions{
+		ExecuteTemplates: true,
+		TargetName:       targetName,
+	})
+	if err != nil {
+		rev.err = err
+		return rev
+	}
+	entry, err := ts.Get(c.fs, targetPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return rev
+	case err != nil:
+		rev.err = err
+		return rev
+	case entry == nil || ts.Ignore(entry.TargetName()):
+		return rev
+	}
+	rev.targetName = entry.TargetName()
+	rev.sourceName = entry.SourceName()
+
+	if c.history.source {
+		if info, err := fs.Lstat(filepath.Join(c.SourceDir, rev.sourceName)); err == nil && info.Mode().IsRegular() {
+			rev.sourceContents, rev.err = fs.ReadFile(filepath.Join(c.SourceDir, rev.sourceName))
+		}
+		return rev
+	}
+
+	snapshot, err := snapshotEntry(entry)
+	if err != nil {
+		rev.err = err
+		return rev
+	}
+	rev.snapshot = &snapshot
+	return rev
+}
+
+// writeTargetRevision writes the change to the target between parentRev,
+// which may be nil, and rev to w.
+func (c *Config) writeTargetRevision(w io.Writer, parentRev, rev *targetRevision) error {
+	if parentRev == nil {
+		parentRev = &targetRevision{}
+	}
+	targetName := rev.targetName
+	if targetName == "" {
+		targetName = parentRev.targetName
+	}
+
+	fmt.Fprintf(w, "%s\n\n", strings.TrimRight(rev.commit.String(), "\n"))
+	switch {
+	case parentRev.sourceName != "" && rev.sourceName != "" && parentRev.sourceName != rev.sourceName:
+		fmt.Fprintf(w, "source: %s => %s\n", parentRev.sourceName, rev.sourceName)
+	case rev.sourceName != "":
+		fmt.Fprintf(w, "source: %s\n", rev.sourceName)
+	case parentRev.sourceName != "":
+		fmt.Fprintf(w, "source: %s (removed)\n", parentRev.sourceName)
+	}
+	if rev.err != nil {
+		fmt.Fprintf(w, "error: %v\n", rev.err)
+	}
+
+	switch {
+	case c.history.source:
+		aName, bName := "/dev/null", "/dev/null"
+		if parentRev.sourceName != "" {
+			aName = "a/" + filepath.ToSlash(parentRev.sourceName)
+		}
+		if rev.sourceName != "" {
+			bName = "b/" + filepath.ToSlash(rev.sourceName)
+		}
+		if !bytes.Equal(parentRev.sourceContents, rev.sourceContents) {
+			if err := chezmoi.WriteUnifiedDiff(w, aName, bName, parentRev.sourceContents, rev.sourceContents, c.colored); err != nil {
+				return err
+			}
+		}
+	default:
+		prevSnapshots := make(map[string]targetSnapshot)
+		if parentRev.snapshot != nil {
+			prevSnapshots[targetName] = *parentRev.snapshot
+		}
+		snapshots := make(map[string]targetSnapshot)
+		if rev.snapshot != nil {
+			snapshots[targetName] = *rev.snapshot
+		}
+		if _, err := c.diffTargetSnapshots(w, prevSnapshots, snapshots); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// changedFrom returns true if the target changed between parentRev, which may
+// be nil, and r.
+func (r *targetRevision) changedFrom(parentRev *targetRevision) bool {
+	if parentRev == nil {
+		return r.sourceName != "" || r.err != nil
+	}
+	switch {
+	case r.sourceName != parentRev.sourceName:
+		return true
+	case (r.err == nil) != (parentRev.err == nil):
+		return true
+	case r.err != nil && r.err.Error() != parentRev.err.Error():
+		return true
+	case (r.snapshot == nil) != (parentRev.snapshot == nil):
+		return true
+	case r.snapshot != nil && (r.snapshot.perm != parentRev.snapshot.perm || !bytes.Equal(r.snapshot.contents, parentRev.snapshot.contents)):
+		return true
+	default:
+		return !bytes.Equal(r.sourceContents, parentRev.sourceContents)
+	}
+}
@@ -3,15 +3,22 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -27,17 +34,57 @@ var initCmd = &cobra.Command{
 }
 
 type initCmdConfig struct {
-	apply bool
+	apply            bool
+	branch           string
+	depth            int
+	oneShot          bool
+	promptBool       map[string]string
+	promptChoice     map[string]string
+	promptDefaults   bool
+	promptInt        map[string]int
+	promptString     map[string]string
+	regenerateConfig bool
+	ssh              bool
 }
 
+var configTemplateContentsSHA256Key = []byte("configTemplateContentsSHA256")
+
+var githubRepoRegexp = regexp.MustCompile(`\A([-0-9A-Za-z]+)(?:/([-.0-9A-Z_a-z]+?)(?:\.git)?)?\z`)
+
 func init() {
 	rootCmd.AddCommand(initCmd)
 
 	persistentFlags := initCmd.PersistentFlags()
 	persistentFlags.BoolVar(&config.init.apply, "apply", false, "update destination directory")
+	persistentFlags.StringVar(&config.init.branch, "branch", "", "check out branch instead of the default branch")
+	persistentFlags.IntVarP(&config.init.depth, "depth", "d", 0, "create a shallow clone with the given depth")
+	persistentFlags.BoolVar(&config.init.oneShot, "one-shot", false, "clone, apply, and then purge all of chezmoi's configuration and data")
+	persistentFlags.StringToStringVar(&config.init.promptBool, "promptBool", nil, "populate promptBool")
+	persistentFlags.StringToStringVar(&config.init.promptChoice, "promptChoice", nil, "populate promptChoice")
+	persistentFlags.BoolVar(&config.init.promptDefaults, "promptDefaults", false, "make prompt functions return default values")
+	persistentFlags.StringToIntVar(&config.init.promptInt, "promptInt", nil, "populate promptInt")
+	persistentFlags.StringToStringVar(&config.init.promptString, "promptString", nil, "populate promptString")
+	persistentFlags.BoolVar(&config.init.regenerateConfig, "regenerate-config", false, "regenerate the config file from the config template")
+	persistentFlags.BoolVar(&config.init.ssh, "ssh", false, "use ssh instead of https when guessing the repo URL")
 }
 
 func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
+	if c.init.regenerateConfig {
+		if len(args) != 0 {
+			return errors.New("--regenerate-config does not take a repo")
+		}
+		return c.createConfigFile()
+	}
+
+	if c.init.oneShot {
+		if len(args) == 0 {
+			return errors.New("--one-shot requires a repo")
+		}
+		if c.init.depth == 0 {
+			c.init.depth = 1
+		}
+	}
+
 	vcs, err := c.getVCS()
 	if err != nil {
 		return err
@@ -54,6 +101,14 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 
 	switch len(args) {
 	case 0: // init
+		if builtinVCS, ok := vcs.(builtinGitVCS); ok {
+			if err := c.runBuiltinVCS(func() error {
+				return builtinVCS.Init(rawSourceDir)
+			}, "init"); err != nil {
+				return err
+			}
+			break
+		}
 		var initArgs []string
 		if c.SourceVCS.Init != nil {
 			switch v := c.SourceVCS.Init.(type) {
@@ -71,40 +126,73 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	case 1: // clone
-		cloneArgs := vcs.CloneArgs(args[0], rawSourceDir)
+		repo := guessRepoURL(args[0], c.init.ssh)
+		if builtinVCS, ok := vcs.(builtinGitVCS); ok {
+			if err := c.runBuiltinVCS(func() error {
+				return builtinVCS.Clone(repo, rawSourceDir, c.init.branch, c.init.depth)
+			}, "clone", repo, rawSourceDir); err != nil {
+				return err
+			}
+			break
+		}
+		cloneArgs := vcs.CloneArgs(repo, rawSourceDir)
 		if cloneArgs == nil {
 			return fmt.Errorf("%s: cloning not supported", c.SourceVCS.Command)
 		}
+		// FIXME this should be part of VCS
+		var cloneOptionArgs []string
+		if c.init.branch != "" {
+			cloneOptionArgs = append(cloneOptionArgs, "--branch", c.init.branch)
+		}
+		if c.init.depth != 0 {
+			if _, ok := vcs.(gitVCS); !ok {
+				return fmt.Errorf("%s: shallow clones not supported", c.SourceVCS.Command)
+			}
+			cloneOptionArgs = append(cloneOptionArgs, "--depth", strconv.Itoa(c.init.depth))
+		}
+		cloneArgs = append(append(cloneArgs[:1:1], cloneOptionArgs...), cloneArgs[1:]...)
 		if err := c.run("", c.SourceVCS.Command, cloneArgs...); err != nil {
 			return err
 		}
-		// FIXME this should be part of VCS
-		if filepath.Base(c.SourceVCS.Command) == "git" {
-			if _, err := c.fs.Stat(filepath.Join(c.SourceDir, ".gitmodules")); err == nil {
-				for _, args := range [][]string{
-					{"submodule", "init"},
-					{"submodule", "update"},
-				} {
-					if err := c.run(c.SourceDir, c.SourceVCS.Command, args...); err != nil {
-						return err
-					}
-				}
-			}
+		if err := c.updateSourceSubmodules(vcs); err != nil {
+			return err
 		}
 	}
 
+	// Ensure that the source state is trusted before executing the config
+	// template, which might call template functions that run commands.
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	if err := c.ensureSourceTrusted(persistentState, false); err != nil {
+		persistentState.Close()
+		return err
+	}
+	if err := persistentState.Close(); err != nil {
+		return err
+	}
+
 	if err := c.createConfigFile(); err != nil {
 		return err
 	}
 
-	if c.init.apply {
+	if c.init.apply || c.init.oneShot {
 		persistentState, err := c.getPersistentState(nil)
 		if err != nil {
 			return err
 		}
-		if err := c.applyArgs(nil, persistentState); err != nil {
+		if err := c.applyArgs(nil, persistentState, false); err != nil {
+			persistentState.Close()
 			return err
 		}
+		if err := persistentState.Close(); err != nil {
+			return err
+		}
+	}
+
+	if c.init.oneShot {
+		return c.doPurge(true)
 	}
 
 	return nil
@@ -117,15 +205,37 @@ func (c *Config) createConfigFile() error {
 	}
 
 	if filename == "" {
-		// no config template file exists
-		return nil
+		// No config template file exists, so forget any previously recorded
+		// config template so that chezmoi does not warn that it has changed.
+		if c.DryRun {
+			return nil
+		}
+		persistentState, err := c.getPersistentState(nil)
+		if err != nil {
+			return err
+		}
+		if err := persistentState.Delete(c.configStateBucket, configTemplateContentsSHA256Key); err != nil {
+			persistentState.Close()
+			return err
+		}
+		return persistentState.Close()
 	}
 
 	funcMap := make(template.FuncMap)
-	for key, value := range c.templateFuncs {
+	for key, value := range c.getTemplateFuncs() {
+		funcMap[key] = value
+	}
+	p := &prompter{
+		bools:    c.init.promptBool,
+		choices:  c.init.promptChoice,
+		ints:     c.init.promptInt,
+		strings:  c.init.promptString,
+		defaults: c.init.promptDefaults,
+		readLine: c.readLine,
+	}
+	for key, value := range p.funcMap() {
 		funcMap[key] = value
 	}
-	funcMap["promptString"] = c.promptString
 	t, err := template.New(filename).Funcs(funcMap).Parse(data)
 	if err != nil {
 		return err
@@ -136,10 +246,16 @@ func (c *Config) createConfigFile() error {
 		return err
 	}
 
+	// Make the data from the existing config file available so that the
+	// prompt*Once functions can reuse previous answers.
+	templateData := make(map[string]interface{})
+	for key, value := range c.Data {
+		templateData[key] = value
+	}
+	templateData["chezmoi"] = defaultData
+
 	contents := &bytes.Buffer{}
-	if err = t.Execute(contents, map[string]interface{}{
-		"chezmoi": defaultData,
-	}); err != nil {
+	if err = t.Execute(contents, templateData); err != nil {
 		return err
 	}
 
@@ -153,6 +269,22 @@ func (c *Config) createConfigFile() error {
 		return err
 	}
 
+	// Record the config template that was used so that we can detect when it
+	// changes.
+	if !c.DryRun {
+		persistentState, err := c.getPersistentState(nil)
+		if err != nil {
+			return err
+		}
+		if err := persistentState.Set(c.configStateBucket, configTemplateContentsSHA256Key, sha256Sum([]byte(data))); err != nil {
+			persistentState.Close()
+			return err
+		}
+		if err := persistentState.Close(); err != nil {
+			return err
+		}
+	}
+
 	viper.SetConfigType(ext)
 	if err := viper.ReadConfig(contents); err != nil {
 		return err
@@ -160,6 +292,36 @@ func (c *Config) createConfigFile() error {
 	return viper.Unmarshal(c)
 }
 
+// configTemplateChanged returns true if the config template has changed since
+// the config file was last generated from it.
+func (c *Config) configTemplateChanged() (bool, error) {
+	_, _, data, err := c.findConfigTemplate()
+	if err != nil {
+		return false, err
+	}
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer persistentState.Close()
+	configTemplateContentsSHA256, err := persistentState.Get(c.configStateBucket, configTemplateContentsSHA256Key)
+	if err != nil {
+		return false, err
+	}
+	if configTemplateContentsSHA256 == nil {
+		// The config file was not generated by a version of chezmoi that
+		// records the config template, so we cannot tell if it has changed.
+		return false, nil
+	}
+	var currentConfigTemplateContentsSHA256 []byte
+	if data != "" {
+		currentConfigTemplateContentsSHA256 = sha256Sum([]byte(data))
+	}
+	return !bytes.Equal(configTemplateContentsSHA256, currentConfigTemplateContentsSHA256), nil
+}
+
 func (c *Config) findConfigTemplate() (string, string, string, error) {
 	for _, ext := range viper.SupportedExts {
 		contents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, ".chezmoi."+ext+chezmoi.TemplateSuffix))
@@ -174,9 +336,173 @@ func (c *Config) findConfigTemplate() (string, string, string, error) {
 	return "", "", "", nil
 }
 
-func (c *Config) promptString(field string) string {
-	fmt.Fprintf(c.Stdout, "%s? ", field)
-	value, err := bufio.NewReader(c.Stdin).ReadString('\n')
-	panicOnError(err)
-	return strings.TrimSpace(value)
+// guessRepoURL returns the URL of the repo given by arg. If arg looks like a
+// GitHub username, or a GitHub username and repo name separated by a slash,
+// then it is expanded to the URL of the corresponding GitHub repo, with the
+// repo name defaulting to dotfiles. Otherwise arg is returned unchanged.
+func guessRepoURL(arg string, ssh bool) string {
+	m := githubRepoRegexp.FindStringSubmatch(arg)
+	if m == nil {
+		return arg
+	}
+	user, repo := m[1], m[2]
+	if repo == "" {
+		repo = "dotfiles"
+	}
+	if ssh {
+		return "git@github.com:" + user + "/" + repo + ".git"
+	}
+	return "https://github.com/" + user + "/" + repo + ".git"
+}
+
+// readLine prints prompt and reads a line from c.Stdin with leading and
+// trailing whitespace removed.
+func (c *Config) readLine(prompt string) (string, error) {
+	if c.stdinReader == nil {
+		c.stdinReader = bufio.NewReader(c.Stdin)
+	}
+	if _, err := fmt.Fprintf(c.Stdout, "%s? ", prompt); err != nil {
+		return "", err
+	}
+	line, err := c.stdinReader.ReadString('\n')
+	if err != nil && !(err == io.EOF && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func boolDefault(args []bool) (bool, bool, error) {
+	switch len(args) {
+	case 0:
+		return false, false, nil
+	case 1:
+		return args[0], true, nil
+	default:
+		return false, false, errors.New("too many arguments")
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intDefault(args []int64) (int64, bool, error) {
+	switch len(args) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return args[0], true, nil
+	default:
+		return 0, false, errors.New("too many arguments")
+	}
+}
+
+// parseBool is like strconv.ParseBool but also accepts common English words.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "n", "no", "off":
+		return false, nil
+	case "y", "yes", "on":
+		return true, nil
+	default:
+		return strconv.ParseBool(s)
+	}
+}
+
+// promptWithDefault returns prompt followed by the default value in args, if
+// any.
+func promptWithDefault(prompt string, args interface{}) string {
+	switch args := args.(type) {
+	case []bool:
+		if len(args) == 1 {
+			return fmt.Sprintf("%s [%t]", prompt, args[0])
+		}
+	case []int64:
+		if len(args) == 1 {
+			return fmt.Sprintf("%s [%d]", prompt, args[0])
+		}
+	case []string:
+		if len(args) == 1 {
+			return fmt.Sprintf("%s [%s]", prompt, args[0])
+		}
+	}
+	return prompt
+}
+
+func stringDefault(args []string) (string, bool, error) {
+	switch len(args) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return args[0], true, nil
+	default:
+		return "", false, errors.New("too many arguments")
+	}
+}
+
+// stringSlice converts choices, which may be the result of sprig's list
+// function, to a []string.
+func stringSlice(choices interface{}) ([]string, error) {
+	switch choices := choices.(type) {
+	case []string:
+		return choices, nil
+	case []interface{}:
+		result := make([]string, 0, len(choices))
+		for _, choice := range choices {
+			s, ok := choice.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v: not a string", choice)
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%v: not a list of strings", choices)
+	}
+}
+
+// sha256Sum returns the hex-encoded SHA256 sum of data.
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return []byte(hex.EncodeToString(sum[:]))
+}
+
+// toInt64 converts value, which may have been decoded from any config file
+// format, to an int64.
+func toInt64(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), true
+	case int64:
+		return value, true
+	case float64:
+		return int64(value), true
+	default:
+		return 0, false
+	}
+}
+
+// valueAtPath returns the value at the dot-separated path in m.
+func valueAtPath(m map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = m
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[key]
+		if !ok {
+			// viper converts all keys to lowercase.
+			value, ok = m[strings.ToLower(key)]
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
 }
@@ -142,3 +142,196 @@ func TestInitRepo(t *testing.T) {
 		),
 	)
 }
+
+func TestCreateConfigFilePromptFuncs(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		template     string
+		options      []configOption
+		stdin        string
+		data         map[string]interface{}
+		wantContents string
+	}{
+		{
+			name:         "promptBool",
+			template:     `{{ promptBool "personal" }}`,
+			stdin:        "maybe\nyes\n",
+			wantContents: "true",
+		},
+		{
+			name:         "promptBool_default",
+			template:     `{{ promptBool "personal" false }}`,
+			stdin:        "\n",
+			wantContents: "false",
+		},
+		{
+			name:         "promptChoice",
+			template:     `{{ promptChoice "type" (list "desktop" "laptop") }}`,
+			stdin:        "server\nlaptop\n",
+			wantContents: "laptop",
+		},
+		{
+			name:         "promptInt",
+			template:     `{{ promptInt "monitors" }}`,
+			stdin:        "two\n2\n",
+			wantContents: "2",
+		},
+		{
+			name:         "promptString_default",
+			template:     `{{ promptString "email" "me@home.org" }}`,
+			stdin:        "\n",
+			wantContents: "me@home.org",
+		},
+		{
+			name:     "promptDefaults",
+			template: `{{ promptBool "personal" true }} {{ promptChoice "type" (list "desktop" "laptop") "desktop" }} {{ promptInt "monitors" 1 }} {{ promptString "email" "me@home.org" }}`,
+			options: []configOption{
+				withInitCmdConfig(initCmdConfig{
+					promptDefaults: true,
+				}),
+			},
+			wantContents: "true desktop 1 me@home.org",
+		},
+		{
+			name:     "prompt_flags",
+			template: `{{ promptBool "personal" }} {{ promptChoice "type" (list "desktop" "laptop") }} {{ promptInt "monitors" }} {{ promptString "email" }}`,
+			options: []configOption{
+				withInitCmdConfig(initCmdConfig{
+					promptBool: map[string]string{
+						"personal": "no",
+					},
+					promptChoice: map[string]string{
+						"type": "laptop",
+					},
+					promptInt: map[string]int{
+						"monitors": 3,
+					},
+					promptString: map[string]string{
+						"email": "me@work.com",
+					},
+				}),
+			},
+			wantContents: "false laptop 3 me@work.com",
+		},
+		{
+			name:     "prompt_once",
+			template: `{{ promptBoolOnce . "personal" "personal" }} {{ promptChoiceOnce . "machine.type" "type" (list "desktop" "laptop") }} {{ promptIntOnce . "monitors" "monitors" }} {{ promptStringOnce . "email" "email" }}`,
+			data: map[string]interface{}{
+				"personal": true,
+				"machine": map[string]interface{}{
+					"type": "laptop",
+				},
+				"monitors": int64(2),
+			},
+			stdin:        "me@home.org\n",
+			wantContents: "true laptop 2 me@home.org",
+		},
+		{
+			name:     "promptChoiceOnce_invalid",
+			template: `{{ promptChoiceOnce . "machine.type" "type" (list "desktop" "laptop") }}`,
+			data: map[string]interface{}{
+				"machine": map[string]interface{}{
+					"type": "server",
+				},
+			},
+			stdin:        "desktop\n",
+			wantContents: "desktop",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoi.yaml.tmpl": "# " + tc.template,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(fs, append([]configOption{
+				withData(tc.data),
+				withStdin(bytes.NewBufferString(tc.stdin)),
+				withStdout(&bytes.Buffer{}),
+			}, tc.options...)...)
+
+			require.NoError(t, c.createConfigFile())
+
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.config/chezmoi/chezmoi.yaml",
+					vfst.TestContentsString("# "+tc.wantContents),
+				),
+			)
+		})
+	}
+}
+
+func TestGuessRepoURL(t *testing.T) {
+	for _, tc := range []struct {
+		arg     string
+		ssh     bool
+		wantURL string
+	}{
+		{
+			arg:     "user",
+			wantURL: "https://github.com/user/dotfiles.git",
+		},
+		{
+			arg:     "user",
+			ssh:     true,
+			wantURL: "git@github.com:user/dotfiles.git",
+		},
+		{
+			arg:     "user/dots",
+			wantURL: "https://github.com/user/dots.git",
+		},
+		{
+			arg:     "user/dots.git",
+			ssh:     true,
+			wantURL: "git@github.com:user/dots.git",
+		},
+		{
+			arg:     "https://gitlab.com/user/dotfiles.git",
+			wantURL: "https://gitlab.com/user/dotfiles.git",
+		},
+		{
+			arg:     "file:///home/user/dotfiles",
+			wantURL: "file:///home/user/dotfiles",
+		},
+		{
+			arg:     "/home/user/dotfiles",
+			wantURL: "/home/user/dotfiles",
+		},
+	} {
+		assert.Equal(t, tc.wantURL, guessRepoURL(tc.arg, tc.ssh))
+	}
+}
+
+func TestConfigTemplateChanged(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": "[data]\n  email = \"user@home.org\"\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+
+	changed, err := c.configTemplateChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, c.createConfigFile())
+	changed, err = c.configTemplateChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl", []byte("[data]\n  email = \"user@work.com\"\n"), 0o666))
+	changed, err = c.configTemplateChanged()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	c.init.regenerateConfig = true
+	require.NoError(t, c.runInitCmd(nil, nil))
+	changed, err = c.configTemplateChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, map[string]interface{}{
+		"email": "user@work.com",
+	}, c.Data)
+}
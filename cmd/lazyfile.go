@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A lazyFile is an io.WriteCloser that creates its file, and any missing
+// parent directories, on the first write.
+type lazyFile struct {
+	fs      vfs.FS
+	path    string
+	perm    os.FileMode
+	f       *os.File
+	created bool
+	err     error
+}
+
+// Close closes f's file, if it was created.
+func (f *lazyFile) Close() error {
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
+
+func (f *lazyFile) Write(p []byte) (int, error) {
+	if f.f == nil && f.err == nil {
+		if f.err = vfs.MkdirAll(f.fs, filepath.Dir(f.path), 0o700); f.err == nil {
+			f.f, f.err = f.fs.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, f.perm)
+			f.created = f.err == nil
+		}
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.f.Write(p)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var lintCmd = &cobra.Command{
+	Use:     "lint",
+	Args:    cobra.NoArgs,
+	Short:   "Check the source state for problems",
+	Long:    mustGetLongHelp("lint"),
+	Example: getExample("lint"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runLintCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func (c *Config) runLintCmd(cmd *cobra.Command, args []string) error {
+	dataProfiles, err := c.getDataProfiles()
+	if err != nil {
+		return err
+	}
+
+	// Do not populate the target state, as that would fail on the first
+	// problem.
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithTemplateFuncs(c.templateFuncs),
+		chezmoi.WithTemplateOptions(c.Template.Options),
+	)
+	diagnostics, err := ts.Lint(vfs.NewReadOnlyFS(c.fs), dataProfiles)
+	if err != nil {
+		return err
+	}
+
+	for _, diagnostic := range diagnostics {
+		if _, err := fmt.Fprintln(c.Stdout, diagnostic); err != nil {
+			return err
+		}
+	}
+	if len(diagnostics) != 0 {
+		return errExitFailure
+	}
+	return nil
+}
+
+// getDataProfiles returns the template data for this machine and for each
+// test case in the source state.
+func (c *Config) getDataProfiles() ([]map[string]interface{}, error) {
+	data, err := c.getData()
+	if err != nil {
+		return nil, err
+	}
+	dataProfiles := []map[string]interface{}{data}
+
+	testsDir := filepath.Join(c.SourceDir, testsDirName)
+	infos, err := c.fs.ReadDir(testsDir)
+	switch {
+	case os.IsNotExist(err):
+		return dataProfiles, nil
+	case err != nil:
+		return nil, err
+	}
+	prevDataFile := c.dataFile
+	defer func() {
+		c.dataFile = prevDataFile
+	}()
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		dataFile := c.getTestCaseDataFile(filepath.Join(testsDir, info.Name()))
+		if dataFile == "" {
+			continue
+		}
+		c.dataFile = dataFile
+		data, err := c.getData()
+		if err != nil {
+			return nil, err
+		}
+		dataProfiles = append(dataProfiles, data)
+	}
+	return dataProfiles, nil
+}
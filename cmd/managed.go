@@ -6,8 +6,6 @@ import (
 	"sort"
 
 	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var managedCmd = &cobra.Command{
@@ -28,44 +26,29 @@ func init() {
 	rootCmd.AddCommand(managedCmd)
 
 	persistentFlags := managedCmd.PersistentFlags()
-	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include")
+	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include entry types")
+	addEntryExcludeFlags(managedCmd)
 }
 
 func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
-	ts, err := c.getTargetState(nil)
+	entryFilter, err := c.getEntryFilter(c.managed.include)
 	if err != nil {
 		return err
 	}
 
-	var (
-		includeDirs     = false
-		includeFiles    = false
-		includeSymlinks = false
-	)
-	for _, what := range c.managed.include {
-		switch what {
-		case "dirs", "d":
-			includeDirs = true
-		case "files", "f":
-			includeFiles = true
-		case "symlinks", "s":
-			includeSymlinks = true
-		default:
-			return fmt.Errorf("unrecognized include: %q", what)
-		}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
 	}
 
 	allEntries := ts.AllEntries()
+	for _, script := range findScripts(ts.Entries) {
+		allEntries = append(allEntries, script)
+	}
 
 	targetNames := make([]string, 0, len(allEntries))
 	for _, entry := range allEntries {
-		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.File); ok && !includeFiles {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.Symlink); ok && !includeSymlinks {
+		if !entryFilter.IncludeEntry(entry) {
 			continue
 		}
 		targetNames = append(targetNames, entry.TargetName())
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// A prompter implements the prompt* template functions. Each function returns
+// the value given for its prompt on the command line, if any. Otherwise, it
+// returns its default value if defaults is true, or else reads a value with
+// readLine. If readLine is nil then the functions never read a value and
+// instead return their default value or a placeholder.
+type prompter struct {
+	bools    map[string]string
+	choices  map[string]string
+	ints     map[string]int
+	strings  map[string]string
+	defaults bool
+	readLine func(prompt string) (string, error)
+}
+
+// funcMap returns p's template functions.
+func (p *prompter) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"promptBool":       p.promptBool,
+		"promptBoolOnce":   p.promptBoolOnce,
+		"promptChoice":     p.promptChoice,
+		"promptChoiceOnce": p.promptChoiceOnce,
+		"promptInt":        p.promptInt,
+		"promptIntOnce":    p.promptIntOnce,
+		"promptString":     p.promptString,
+		"promptStringOnce": p.promptStringOnce,
+	}
+}
+
+func (p *prompter) promptBool(prompt string, args ...bool) bool {
+	defaultValue, hasDefault, err := boolDefault(args)
+	panicOnError(err)
+	if valueStr, ok := p.bools[prompt]; ok {
+		value, err := parseBool(valueStr)
+		panicOnError(err)
+		return value
+	}
+	if (hasDefault && p.defaults) || p.readLine == nil {
+		return defaultValue
+	}
+	for {
+		line, err := p.readLine(promptWithDefault(prompt, args))
+		panicOnError(err)
+		if line == "" && hasDefault {
+			return defaultValue
+		}
+		if value, err := parseBool(line); err == nil {
+			return value
+		}
+	}
+}
+
+func (p *prompter) promptBoolOnce(m map[string]interface{}, path, prompt string, args ...bool) bool {
+	if value, ok := valueAtPath(m, path); ok {
+		if value, ok := value.(bool); ok {
+			return value
+		}
+	}
+	return p.promptBool(prompt, args...)
+}
+
+func (p *prompter) promptChoice(prompt string, choices interface{}, args ...string) string {
+	choicesStrs, err := stringSlice(choices)
+	panicOnError(err)
+	if len(choicesStrs) == 0 {
+		panic(fmt.Errorf("no choices for %q", prompt))
+	}
+	defaultValue, hasDefault, err := stringDefault(args)
+	panicOnError(err)
+	if hasDefault && !containsString(choicesStrs, defaultValue) {
+		panic(fmt.Errorf("%s: invalid default value for %q", defaultValue, prompt))
+	}
+	if value, ok := p.choices[prompt]; ok {
+		if !containsString(choicesStrs, value) {
+			panic(fmt.Errorf("%s: invalid value for %q", value, prompt))
+		}
+		return value
+	}
+	if hasDefault && (p.defaults || p.readLine == nil) {
+		return defaultValue
+	}
+	if p.readLine == nil {
+		return choicesStrs[0]
+	}
+	choicesPrompt := prompt + " (" + strings.Join(choicesStrs, "/") + ")"
+	for {
+		line, err := p.readLine(promptWithDefault(choicesPrompt, args))
+		panicOnError(err)
+		if line == "" && hasDefault {
+			return defaultValue
+		}
+		if containsString(choicesStrs, line) {
+			return line
+		}
+	}
+}
+
+func (p *prompter) promptChoiceOnce(m map[string]interface{}, path, prompt string, choices interface{}, args ...string) string {
+	if value, ok := valueAtPath(m, path); ok {
+		// Prompt again if the previous answer is no longer one of the
+		// choices.
+		if value, ok := value.(string); ok {
+			choicesStrs, err := stringSlice(choices)
+			panicOnError(err)
+			if containsString(choicesStrs, value) {
+				return value
+			}
+		}
+	}
+	return p.promptChoice(prompt, choices, args...)
+}
+
+func (p *prompter) promptInt(prompt string, args ...int64) int64 {
+	defaultValue, hasDefault, err := intDefault(args)
+	panicOnError(err)
+	if value, ok := p.ints[prompt]; ok {
+		return int64(value)
+	}
+	if (hasDefault && p.defaults) || p.readLine == nil {
+		return defaultValue
+	}
+	for {
+		line, err := p.readLine(promptWithDefault(prompt, args))
+		panicOnError(err)
+		if line == "" && hasDefault {
+			return defaultValue
+		}
+		if value, err := strconv.ParseInt(line, 10, 64); err == nil {
+			return value
+		}
+	}
+}
+
+func (p *prompter) promptIntOnce(m map[string]interface{}, path, prompt string, args ...int64) int64 {
+	if value, ok := valueAtPath(m, path); ok {
+		if value, ok := toInt64(value); ok {
+			return value
+		}
+	}
+	return p.promptInt(prompt, args...)
+}
+
+func (p *prompter) promptString(prompt string, args ...string) string {
+	defaultValue, hasDefault, err := stringDefault(args)
+	panicOnError(err)
+	if value, ok := p.strings[prompt]; ok {
+		return value
+	}
+	if hasDefault && (p.defaults || p.readLine == nil) {
+		return defaultValue
+	}
+	if p.readLine == nil {
+		return prompt
+	}
+	line, err := p.readLine(promptWithDefault(prompt, args))
+	panicOnError(err)
+	if line == "" && hasDefault {
+		return defaultValue
+	}
+	return line
+}
+
+func (p *prompter) promptStringOnce(m map[string]interface{}, path, prompt string, args ...string) string {
+	if value, ok := valueAtPath(m, path); ok {
+		if value, ok := value.(string); ok {
+			return value
+		}
+	}
+	return p.promptString(prompt, args...)
+}
@@ -29,6 +29,12 @@ func init() {
 }
 
 func (c *Config) runPurgeCmd(cmd *cobra.Command, args []string) error {
+	return c.doPurge(c.purge.force)
+}
+
+// doPurge removes all of chezmoi's configuration and data, prompting for each
+// path unless force is true.
+func (c *Config) doPurge(force bool) error {
 	// Build a list of chezmoi-related paths.
 	var paths []string
 	for _, dirs := range [][]string{
@@ -39,7 +45,7 @@ func (c *Config) runPurgeCmd(cmd *cobra.Command, args []string) error {
 			paths = append(paths, filepath.Join(dir, "chezmoi"))
 		}
 	}
-	paths = append(paths, c.configFile, c.getPersistentStateFile())
+	paths = append(paths, c.configFile, c.getPersistentStateFile(), c.SourceDir)
 
 	// Remove all paths that exist.
 PATH:
@@ -51,14 +57,14 @@ PATH:
 		case err != nil:
 			return err
 		}
-		if !c.purge.force {
+		if !force {
 			choice, err := c.prompt(fmt.Sprintf("Remove %s", path), "ynqa")
 			if err != nil {
 				return err
 			}
 			switch choice {
 			case 'a':
-				c.purge.force = true
+				force = true
 			case 'n':
 				continue PATH
 			case 'q':
@@ -65,6 +65,8 @@ func init() {
 	persistentFlags.BoolVar(&config.Follow, "follow", false, "follow symlinks")
 	panicOnError(viper.BindPFlag("follow", persistentFlags.Lookup("follow")))
 
+	persistentFlags.BoolVar(&config.noScripts, "no-scripts", false, "do not run scripts or template functions that run commands")
+
 	persistentFlags.BoolVar(&config.Remove, "remove", false, "remove targets")
 	panicOnError(viper.BindPFlag("remove", persistentFlags.Lookup("remove")))
 
@@ -104,12 +106,18 @@ func init() {
 					"warning: to disable this warning, set gpg.recipient in your config file instead\n",
 				)
 			}
-			if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit && !strings.Contains(filepath.Base(config.SourceVCS.Command), "git") {
-				rootCmd.Printf("" +
-					"warning: it looks like you are using a version control system that is not git which will be deprecated in v2\n" +
-					"warning: please report this at https://github.com/twpayne/chezmoi/issues/459\n" +
-					"warning: to disable this warning, set sourceVCS.notGit = true in your config file\n",
-				)
+			if config.SourceVCS.Command != "" && !config.SourceVCS.NotGit {
+				name := trimExecutableSuffix(filepath.Base(config.SourceVCS.Command))
+				_, isBuiltin := vcses[name]
+				_, isBackend := config.SourceVCS.Backends[strings.ToLower(name)]
+				if !isBuiltin && !isBackend {
+					rootCmd.Printf(""+
+						"warning: %s: unsupported source VCS command\n"+
+						"warning: to add support for it, define sourceVCS.backends.%s in your config file\n"+
+						"warning: to disable this warning, set sourceVCS.notGit = true in your config file\n",
+						config.SourceVCS.Command, name,
+					)
+				}
 			}
 		case os.IsNotExist(err):
 		default:
@@ -205,6 +213,17 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 		return err
 	}
 
+	if cmd != initCmd && cmd != purgeCmd {
+		if changed, err := c.configTemplateChanged(); err != nil {
+			return err
+		} else if changed {
+			cmd.Printf("" +
+				"warning: config file template has changed\n" +
+				"warning: to regenerate your config file, run chezmoi init --regenerate-config\n",
+			)
+		}
+	}
+
 	// Apply any fixes for snap, if needed.
 	return c.snapFix()
 }
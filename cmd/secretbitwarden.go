@@ -27,7 +27,7 @@ var bitwardenCache = make(map[string]interface{})
 
 func init() {
 	config.Bitwarden.Command = "bw"
-	config.addTemplateFunc("bitwarden", config.bitwardenFunc)
+	config.addExecTemplateFunc("bitwarden", config.bitwardenFunc)
 
 	secretCmd.AddCommand(bitwardenCmd)
 }
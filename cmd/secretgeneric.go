@@ -30,8 +30,8 @@ var (
 )
 
 func init() {
-	config.addTemplateFunc("secret", config.secretFunc)
-	config.addTemplateFunc("secretJSON", config.secretJSONFunc)
+	config.addExecTemplateFunc("secret", config.secretFunc)
+	config.addExecTemplateFunc("secretJSON", config.secretJSONFunc)
 
 	secretCmd.AddCommand(genericSecretCmd)
 }
@@ -27,7 +27,7 @@ func init() {
 	secretCmd.AddCommand(gopassCmd)
 
 	config.Gopass.Command = "gopass"
-	config.addTemplateFunc("gopass", config.gopassFunc)
+	config.addExecTemplateFunc("gopass", config.gopassFunc)
 }
 
 func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
@@ -47,8 +47,8 @@ var (
 
 func init() {
 	config.KeePassXC.Command = "keepassxc-cli"
-	config.addTemplateFunc("keepassxc", config.keePassXCFunc)
-	config.addTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
+	config.addExecTemplateFunc("keepassxc", config.keePassXCFunc)
+	config.addExecTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
 
 	secretCmd.AddCommand(keePassXCCmd)
 }
@@ -42,8 +42,8 @@ var lastPassCache = make(map[string][]map[string]interface{})
 
 func init() {
 	config.Lastpass.Command = "lpass"
-	config.addTemplateFunc("lastpass", config.lastpassFunc)
-	config.addTemplateFunc("lastpassRaw", config.lastpassRawFunc)
+	config.addExecTemplateFunc("lastpass", config.lastpassFunc)
+	config.addExecTemplateFunc("lastpassRaw", config.lastpassRawFunc)
 
 	secretCmd.AddCommand(lastpassCmd)
 }
@@ -29,8 +29,8 @@ var (
 
 func init() {
 	config.Onepassword.Command = "op"
-	config.addTemplateFunc("onepassword", config.onepasswordFunc)
-	config.addTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
+	config.addExecTemplateFunc("onepassword", config.onepasswordFunc)
+	config.addExecTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
 
 	secretCmd.AddCommand(onepasswordCmd)
 }
@@ -27,7 +27,7 @@ func init() {
 	secretCmd.AddCommand(passCmd)
 
 	config.Pass.Command = "pass"
-	config.addTemplateFunc("pass", config.passFunc)
+	config.addExecTemplateFunc("pass", config.passFunc)
 }
 
 func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
@@ -26,7 +26,7 @@ var vaultCache = make(map[string]interface{})
 
 func init() {
 	config.Vault.Command = "vault"
-	config.addTemplateFunc("vault", config.vaultFunc)
+	config.addExecTemplateFunc("vault", config.vaultFunc)
 
 	secretCmd.AddCommand(vaultCmd)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -18,5 +20,8 @@ func init() {
 }
 
 func (c *Config) runSourceCmd(cmd *cobra.Command, args []string) error {
+	if c.SourceVCS.Command == builtinVCSCommand {
+		return fmt.Errorf("%s: source command not supported, use chezmoi git instead", c.SourceVCS.Command)
+	}
 	return c.run(c.SourceDir, c.SourceVCS.Command, args...)
 }
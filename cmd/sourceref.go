@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// getSourceFS returns the FS from which the source state is read: the source
+// directory, or, if --source-ref is set, the source directory at that
+// revision.
+func (c *Config) getSourceFS() (vfs.FS, error) {
+	if c.sourceRef == "" {
+		return vfs.NewReadOnlyFS(c.fs), nil
+	}
+	return c.getSourceRevisionFS(c.sourceRef)
+}
+
+// getSourceRevision returns the revision from which the source state is read.
+func (c *Config) getSourceRevision() string {
+	if c.sourceRef == "" {
+		return "HEAD"
+	}
+	return c.sourceRef
+}
+
+// getSourceRevisionFS returns a read-only FS containing the source directory
+// at rev, read from the git repository that contains the source directory.
+func (c *Config) getSourceRevisionFS(rev string) (vfs.FS, error) {
+	repo, prefix, err := c.openSourceRepo()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := c.resolveSourceRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rev, err)
+	}
+	return c.getCommitSourceFS(commit, prefix)
+}
+
+// getCommitSourceFS returns a read-only FS containing the source directory,
+// which is at prefix in the repository, at commit.
+func (c *Config) getCommitSourceFS(commit *object.Commit, prefix string) (vfs.FS, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", commit.Hash, err)
+	}
+	if prefix != "." {
+		tree, err = tree.Tree(filepath.ToSlash(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", commit.Hash, prefix, err)
+		}
+	}
+	return git.NewTreeFS(tree, c.SourceDir, commit.Committer.When), nil
+}
+
+// openSourceRepo opens the git repository that contains the source directory
+// and returns it with the path of the source directory relative to the
+// repository's worktree.
+func (c *Config) openSourceRepo() (*gogit.Repository, string, error) {
+	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := gogit.PlainOpenWithOptions(rawSourceDir, &gogit.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", c.SourceDir, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, "", err
+	}
+	prefix, err := relEvalSymlinks(worktree.Filesystem.Root(), rawSourceDir)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, prefix, nil
+}
+
+// resolveSourceRevision returns the hash of the commit that rev refers to in
+// repo.
+func (c *Config) resolveSourceRevision(repo *gogit.Repository, rev string) (plumbing.Hash, error) {
+	// git rev-parse understands more revisions than go-git, for example
+	// abbreviated hashes and annotated tags, so prefer it if it is available.
+	if vcs, err := c.getVCS(); err == nil {
+		if _, ok := vcs.(gitVCS); ok {
+			if output, err := c.sourceVCSOutput("rev-parse", "--verify", "--quiet", rev+"^{commit}"); err == nil {
+				return plumbing.NewHash(output), nil
+			}
+		}
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%s: %w", rev, err)
+	}
+	return *hash, nil
+}
+
+// relEvalSymlinks returns the relative path from basepath to targpath after
+// evaluating any symlinks in both.
+func relEvalSymlinks(basepath, targpath string) (string, error) {
+	basepath, err := filepath.EvalSymlinks(basepath)
+	if err != nil {
+		return "", err
+	}
+	targpath, err = filepath.EvalSymlinks(targpath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(basepath, targpath)
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+type sourceStatusCmdConfig struct {
+	format string
+}
+
+// A sourceStatus is the sync state of the source repository. Signature is only
+// set if update.verifySignatures is set.
+type sourceStatus struct {
+	Branch    string `json:"branch" toml:"branch" yaml:"branch"`
+	Upstream  string `json:"upstream" toml:"upstream" yaml:"upstream"`
+	State     string `json:"state" toml:"state" yaml:"state"`
+	Ahead     int    `json:"ahead" toml:"ahead" yaml:"ahead"`
+	Behind    int    `json:"behind" toml:"behind" yaml:"behind"`
+	Dirty     bool   `json:"dirty" toml:"dirty" yaml:"dirty"`
+	Stashes   int    `json:"stashes" toml:"stashes" yaml:"stashes"`
+	Signature string `json:"signature,omitempty" toml:"signature,omitempty" yaml:"signature,omitempty"`
+}
+
+// Source states.
+const (
+	sourceStateAhead      = "ahead"
+	sourceStateBehind     = "behind"
+	sourceStateDiverged   = "diverged"
+	sourceStateNoUpstream = "no-upstream"
+	sourceStateUpToDate   = "up-to-date"
+)
+
+// Signature states.
+const (
+	signatureStateUnverified = "unverified"
+	signatureStateVerified   = "verified"
+)
+
+var sourceStatusCmd = &cobra.Command{
+	Use:     "source-status",
+	Args:    cobra.NoArgs,
+	Short:   "Print the sync state of the source repository",
+	Long:    mustGetLongHelp("source-status"),
+	Example: getExample("source-status"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSourceStatusCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(sourceStatusCmd)
+
+	persistentFlags := sourceStatusCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.sourceStatus.format, "format", "f", "text", "format (text, JSON, TOML, or YAML)")
+}
+
+func (c *Config) runSourceStatusCmd(cmd *cobra.Command, args []string) error {
+	formatName := strings.ToLower(c.sourceStatus.format)
+	format, ok := formatMap[formatName]
+	if !ok && formatName != "text" {
+		return fmt.Errorf("%s: unknown format", c.sourceStatus.format)
+	}
+	status, err := c.getSourceStatus()
+	if err != nil {
+		return err
+	}
+	if format != nil {
+		return format(c.Stdout, status)
+	}
+	_, err = fmt.Fprintln(c.Stdout, status)
+	return err
+}
+
+// getSourceStatus returns the sync state of the source repository. Only git
+// is supported.
+func (c *Config) getSourceStatus() (*sourceStatus, error) {
+	vcs, err := c.getVCS()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := vcs.(gitVCS); !ok {
+		return nil, fmt.Errorf("%s: source status is only supported for git", c.SourceVCS.Command)
+	}
+
+	output, err := c.sourceVCSOutput("status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil, err
+	}
+	gitStatus, err := git.ParseStatusPorcelainV2([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	if gitStatus == nil {
+		gitStatus = &git.Status{}
+	}
+	// git status only reports the number of stashes with --show-stash, which
+	// requires git 2.35 or later, so count them directly.
+	stashList, err := c.sourceVCSOutput("stash", "list")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &sourceStatus{
+		Branch:   gitStatus.Branch.Head,
+		Upstream: gitStatus.Branch.Upstream,
+		Ahead:    gitStatus.Branch.Ahead,
+		Behind:   gitStatus.Branch.Behind,
+		Dirty:    !gitStatus.Empty(),
+	}
+	if stashList != "" {
+		status.Stashes = len(strings.Split(stashList, "\n"))
+	}
+	switch {
+	case status.Upstream == "":
+		status.State = sourceStateNoUpstream
+	case status.Ahead != 0 && status.Behind != 0:
+		status.State = sourceStateDiverged
+	case status.Ahead != 0:
+		status.State = sourceStateAhead
+	case status.Behind != 0:
+		status.State = sourceStateBehind
+	default:
+		status.State = sourceStateUpToDate
+	}
+	if c.Update.VerifySignatures {
+		if err := c.verifySourceRevisionSignatures([]string{"HEAD"}); err != nil {
+			status.Signature = signatureStateUnverified
+		} else {
+			status.Signature = signatureStateVerified
+		}
+	}
+	return status, nil
+}
+
+// InSync returns true if s has no uncommitted changes and is neither ahead of
+// nor behind its upstream.
+func (s *sourceStatus) InSync() bool {
+	return !s.Dirty && s.Ahead == 0 && s.Behind == 0
+}
+
+func (s *sourceStatus) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(s.Branch)
+	if s.Upstream != "" {
+		sb.WriteString("..." + s.Upstream)
+	}
+	switch s.State {
+	case sourceStateAhead:
+		fmt.Fprintf(sb, ": ahead %d", s.Ahead)
+	case sourceStateBehind:
+		fmt.Fprintf(sb, ": behind %d", s.Behind)
+	case sourceStateDiverged:
+		fmt.Fprintf(sb, ": diverged, ahead %d, behind %d", s.Ahead, s.Behind)
+	case sourceStateNoUpstream:
+		sb.WriteString(": no upstream")
+	default:
+		sb.WriteString(": up to date")
+	}
+	if s.Dirty {
+		sb.WriteString(", uncommitted changes")
+	}
+	switch s.Signature {
+	case signatureStateUnverified:
+		sb.WriteString(", HEAD signature not verified")
+	case signatureStateVerified:
+		sb.WriteString(", HEAD signature verified")
+	}
+	switch s.Stashes {
+	case 0:
+	case 1:
+		sb.WriteString(", 1 stash")
+	default:
+		fmt.Fprintf(sb, ", %d stashes", s.Stashes)
+	}
+	return sb.String()
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var syncCmd = &cobra.Command{
+	Use:     "sync",
+	Args:    cobra.NoArgs,
+	Short:   "Pull changes, add back edited files, commit, push, and apply",
+	Long:    mustGetLongHelp("sync"),
+	Example: getExample("sync"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSyncCmd,
+}
+
+// An entryState records the state of a target when it was last applied.
+type entryState struct {
+	ContentsSHA256 string `json:"contentsSHA256"`
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	addEntryFilterFlags(syncCmd)
+}
+
+func (c *Config) runSyncCmd(cmd *cobra.Command, args []string) error {
+	vcs, err := c.getVCS()
+	if err != nil {
+		return err
+	}
+
+	// Always preview every step in dry run mode.
+	if c.DryRun && !c.Verbose {
+		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if _, err := c.pullSource(vcs); err != nil {
+		return err
+	}
+
+	if err := c.ensureSourceTrusted(persistentState, false); err != nil {
+		return err
+	}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	// Create a temporary directory to store the target state for merges.
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	var syncedTargets []string
+	for _, entry := range ts.AllEntries() {
+		file, ok := entry.(*chezmoi.File)
+		if !ok || ignoredTarget(ts, file.TargetName()) {
+			continue
+		}
+		targetPath := filepath.Join(ts.DestDir, file.TargetName())
+		destContents, err := c.fs.ReadFile(targetPath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(destContents, contents) {
+			continue
+		}
+
+		var es entryState
+		entryStateData, err := persistentState.Get(c.entryStateBucket, []byte(file.TargetName()))
+		if err != nil {
+			return err
+		}
+		if entryStateData != nil {
+			if err := json.Unmarshal(entryStateData, &es); err != nil {
+				return err
+			}
+		}
+		switch {
+		case es.ContentsSHA256 == string(sha256Sum(destContents)):
+			// The destination file has not been edited since it was last
+			// applied, so apply any changes from the source state.
+		case es.ContentsSHA256 == string(sha256Sum(contents)) && !file.Template:
+			// Only the destination file has been edited, so add it back.
+			if err := ts.Add(c.fs, chezmoi.AddOptions{
+				Empty:   file.Empty,
+				Encrypt: file.Encrypted,
+			}, targetPath, nil, false, c.mutator); err != nil {
+				return err
+			}
+			syncedTargets = append(syncedTargets, targetPath)
+		default:
+			// Both have been edited, the file is a template, or the state of
+			// the destination file when it was last applied is unknown.
+			if err := c.runMergeCommand(cmd, targetPath, file, tempDir); err != nil {
+				return err
+			}
+			syncedTargets = append(syncedTargets, targetPath)
+		}
+	}
+
+	if err := c.autoCommit(vcs, cmd.Name(), syncedTargets); err != nil {
+		return err
+	}
+	if err := c.autoPush(vcs); err != nil {
+		return err
+	}
+
+	return c.applyArgs(nil, persistentState, false)
+}
+
+// recordEntryStates records the state of the files in entries that were
+// applied from ts. Files that ts ignores, or that are in directories that ts
+// ignores, were not applied and so are not recorded.
+func (c *Config) recordEntryStates(persistentState chezmoi.PersistentState, ts *chezmoi.TargetState, entries []chezmoi.Entry) error {
+	for _, entry := range entries {
+		file, ok := entry.(*chezmoi.File)
+		if !ok || ignoredTarget(ts, file.TargetName()) {
+			continue
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		key := []byte(file.TargetName())
+		if len(bytes.TrimSpace(contents)) == 0 && !file.Empty {
+			// The file was removed.
+			if err := persistentState.Delete(c.entryStateBucket, key); err != nil {
+				return err
+			}
+			continue
+		}
+		entryStateData, err := json.Marshal(&entryState{
+			ContentsSHA256: string(sha256Sum(contents)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := persistentState.Set(c.entryStateBucket, key, entryStateData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ignoredTarget returns true if ts ignores targetName or any of its parent
+// directories.
+func ignoredTarget(ts *chezmoi.TargetState, targetName string) bool {
+	for name := targetName; name != "."; name = filepath.Dir(name) {
+		if ts.Ignore(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+const (
+	testsDirName          = ".chezmoitests"
+	testArchiveName       = "archive.txt"
+	testDataBaseName      = "data"
+	testTargetsDirName    = "targets"
+	testDeterministicMask = 0o22
+)
+
+var testCmd = &cobra.Command{
+	Use:     "test [cases...]",
+	Short:   "Test the rendered target state against golden files",
+	Long:    mustGetLongHelp("test"),
+	Example: getExample("test"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runTestCmd,
+}
+
+type testCmdConfig struct {
+	format string
+	update bool
+}
+
+// A testResult is the result of a single test.
+type testResult struct {
+	caseName string
+	name     string
+	mismatch bool
+	want     []byte
+	got      []byte
+	err      error
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	persistentFlags := testCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.test.format, "format", "f", "text", "format (text, tap, or junit)")
+	persistentFlags.BoolVarP(&config.test.update, "update", "u", false, "update golden files")
+}
+
+func (c *Config) runTestCmd(cmd *cobra.Command, args []string) error {
+	var writeResults func(io.Writer, []testResult) error
+	switch strings.ToLower(c.test.format) {
+	case "text":
+		writeResults = c.writeTestResultsText
+	case "tap":
+		writeResults = writeTestResultsTAP
+	case "junit":
+		writeResults = writeTestResultsJUnit
+	default:
+		return fmt.Errorf("%s: unknown format", c.test.format)
+	}
+	if err := c.ensureRenderTrusted(); err != nil {
+		return err
+	}
+
+	testsDir := filepath.Join(c.SourceDir, testsDirName)
+	caseNames := args
+	if len(caseNames) == 0 {
+		infos, err := c.fs.ReadDir(testsDir)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				caseNames = append(caseNames, info.Name())
+			}
+		}
+	}
+	sort.Strings(caseNames)
+
+	var results []testResult
+	for _, caseName := range caseNames {
+		caseResults, err := c.runTestCase(filepath.Join(testsDir, caseName))
+		if err != nil {
+			caseResults = []testResult{
+				{
+					name: caseName,
+					err:  err,
+				},
+			}
+		}
+		for i := range caseResults {
+			caseResults[i].caseName = caseName
+		}
+		results = append(results, caseResults...)
+	}
+
+	if err := writeResults(c.Stdout, results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.err != nil || result.mismatch {
+			return errExitFailure
+		}
+	}
+	return nil
+}
+
+// runTestCase renders the target state with the data in caseDir and compares
+// it with the golden files in caseDir.
+func (c *Config) runTestCase(caseDir string) ([]testResult, error) {
+	// Render the target state deterministically with the case's data.
+	prevDataFile, prevUmask := c.dataFile, c.Umask
+	defer func() {
+		c.dataFile, c.Umask = prevDataFile, prevUmask
+	}()
+	c.dataFile = c.getTestCaseDataFile(caseDir)
+	c.Umask = testDeterministicMask
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []testResult
+
+	targetsDir := filepath.Join(caseDir, testTargetsDirName)
+	if err := vfs.Walk(c.fs, targetsDir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err) && path == targetsDir:
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		}
+		targetName, err := filepath.Rel(targetsDir, path)
+		if err != nil {
+			return err
+		}
+		want, err := c.fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		got, err := renderTarget(c.fs, ts, targetName)
+		results = append(results, c.compareGolden(filepath.ToSlash(targetName), path, want, got, err))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	archivePath := filepath.Join(caseDir, testArchiveName)
+	switch want, err := c.fs.ReadFile(archivePath); {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		got, err := renderArchiveText(ts)
+		results = append(results, c.compareGolden(testArchiveName, archivePath, want, got, err))
+	}
+
+	if results == nil {
+		return nil, fmt.Errorf("%s: no golden files", caseDir)
+	}
+	return results, nil
+}
+
+// getTestCaseDataFile returns the path to the data file in caseDir, or the
+// empty string if caseDir does not contain a data file.
+func (c *Config) getTestCaseDataFile(caseDir string) string {
+	for _, ext := range []string{"json", "toml", "yaml", "yml"} {
+		dataFile := filepath.Join(caseDir, testDataBaseName+"."+ext)
+		if _, err := c.fs.Stat(dataFile); err == nil {
+			return dataFile
+		}
+	}
+	return ""
+}
+
+// compareGolden compares want, read from the golden file at path, with got,
+// updating the golden file if requested.
+func (c *Config) compareGolden(name, path string, want, got []byte, err error) testResult {
+	result := testResult{
+		name: name,
+	}
+	switch {
+	case err != nil:
+		result.err = err
+	case bytes.Equal(want, got):
+	case c.test.update:
+		result.err = c.mutator.WriteFile(path, got, 0o666&^os.FileMode(c.Umask), want)
+	default:
+		result.mismatch = true
+		result.want = want
+		result.got = got
+	}
+	return result
+}
+
+// diff returns the unified diff between the golden and rendered contents of
+// result.
+func (result *testResult) diff(colored bool) (string, error) {
+	sb := &strings.Builder{}
+	if err := chezmoi.WriteUnifiedDiff(sb, filepath.Join("want", result.name), filepath.Join("got", result.name), result.want, result.got, colored); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (c *Config) writeTestResultsText(w io.Writer, results []testResult) error {
+	failures := 0
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			failures++
+			fmt.Fprintf(w, "FAIL %s/%s: %v\n", result.caseName, result.name, result.err)
+		case result.mismatch:
+			failures++
+			diff, err := result.diff(c.colored)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "FAIL %s/%s\n%s", result.caseName, result.name, diff)
+		default:
+			fmt.Fprintf(w, "ok   %s/%s\n", result.caseName, result.name)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d tests, %d failed\n", len(results), failures)
+	return err
+}
+
+// renderArchiveText returns a textual representation of the archive of ts,
+// suitable for comparing with a golden file.
+func renderArchiveText(ts *chezmoi.TargetState) ([]byte, error) {
+	archive := &bytes.Buffer{}
+	w := tar.NewWriter(archive)
+	if err := ts.Archive(w, ts.Umask); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	r := tar.NewReader(archive)
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fmt.Fprintf(b, "==> %s %03o <==\n", header.Name, header.Mode&0o7777)
+		case tar.TypeReg:
+			fmt.Fprintf(b, "==> %s %03o <==\n", header.Name, header.Mode&0o7777)
+			contents := &bytes.Buffer{}
+			if _, err := io.Copy(contents, r); err != nil {
+				return nil, err
+			}
+			b.Write(contents.Bytes())
+			if contents.Len() != 0 && !bytes.HasSuffix(contents.Bytes(), []byte("\n")) {
+				b.WriteString("\n")
+			}
+		case tar.TypeSymlink:
+			fmt.Fprintf(b, "==> %s -> %s <==\n", header.Name, header.Linkname)
+		default:
+			return nil, fmt.Errorf("%s: unsupported typeflag '%c'", header.Name, header.Typeflag)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// renderTarget returns the rendered target state of targetName in ts.
+func renderTarget(fs vfs.Stater, ts *chezmoi.TargetState, targetName string) ([]byte, error) {
+	if ts.Ignore(targetName) {
+		return nil, fmt.Errorf("%s: ignored", targetName)
+	}
+	entry, err := ts.Get(fs, filepath.Join(ts.DestDir, targetName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: not in target state", targetName)
+	} else if err != nil {
+		return nil, err
+	}
+	switch entry := entry.(type) {
+	case *chezmoi.File:
+		return entry.Contents()
+	case *chezmoi.Script:
+		return entry.Contents()
+	case *chezmoi.Symlink:
+		linkname, err := entry.Linkname()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(linkname + "\n"), nil
+	default:
+		return nil, fmt.Errorf("%s: not a file, script, or symlink", targetName)
+	}
+}
+
+func writeTestResultsJUnit(w io.Writer, results []testResult) error {
+	testSuites := &junitTestSuites{}
+	suiteIndex := make(map[string]int)
+	for _, result := range results {
+		i, ok := suiteIndex[result.caseName]
+		if !ok {
+			i = len(testSuites.Suites)
+			suiteIndex[result.caseName] = i
+			testSuites.Suites = append(testSuites.Suites, junitTestSuite{
+				Name: result.caseName,
+			})
+		}
+		suite := &testSuites.Suites[i]
+		testCase := junitTestCase{
+			Name:      result.name,
+			ClassName: result.caseName,
+		}
+		switch {
+		case result.err != nil:
+			suite.Errors++
+			testCase.Error = &junitFailure{
+				Message: result.err.Error(),
+			}
+		case result.mismatch:
+			diff, err := result.diff(false)
+			if err != nil {
+				return err
+			}
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message:  "rendered output does not match golden file",
+				Contents: diff,
+			}
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(testSuites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func writeTestResultsTAP(w io.Writer, results []testResult) error {
+	fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(results))
+	for i, result := range results {
+		switch {
+		case result.err != nil:
+			fmt.Fprintf(w, "not ok %d - %s/%s\n", i+1, result.caseName, result.name)
+			fmt.Fprintf(w, "  ---\n  message: %q\n  ...\n", result.err.Error())
+		case result.mismatch:
+			diff, err := result.diff(false)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "not ok %d - %s/%s\n", i+1, result.caseName, result.name)
+			fmt.Fprintf(w, "  ---\n  message: rendered output does not match golden file\n  diff: |\n")
+			for _, line := range strings.SplitAfter(strings.TrimSuffix(diff, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s", line)
+			}
+			fmt.Fprintf(w, "\n  ...\n")
+		default:
+			fmt.Fprintf(w, "ok %d - %s/%s\n", i+1, result.caseName, result.name)
+		}
+	}
+	return nil
+}
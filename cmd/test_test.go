@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestRenderArchiveText(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"dot_dir": map[string]interface{}{
+				"file.tmpl": "{{ .name }}",
+			},
+			"private_dot_secret": "secret\n",
+			"symlink_dot_link":   ".secret\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withData(map[string]interface{}{
+			"name": "value",
+		}),
+	)
+	c.Umask = testDeterministicMask
+	ts, err := c.getTargetState(nil)
+	require.NoError(t, err)
+
+	actual, err := renderArchiveText(ts)
+	require.NoError(t, err)
+	assert.Equal(t, "==> .dir/ 755 <==\n"+
+		"==> .dir/file 644 <==\n"+
+		"value\n"+
+		"==> .link -> .secret <==\n"+
+		"==> .secret 600 <==\n"+
+		"secret\n", string(actual))
+
+	actualLink, err := renderTarget(fs, ts, ".link")
+	require.NoError(t, err)
+	assert.Equal(t, ".secret\n", string(actualLink))
+
+	_, err = renderTarget(fs, ts, ".missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// A trustState records the approval of a source state.
+type trustState struct {
+	TrustedAt time.Time `json:"trustedAt"`
+}
+
+// ensureSourceTrusted returns nil if the source state can run commands. If
+// the source state contains scripts or calls template functions that run
+// commands, and it has not already been approved, then the user is prompted
+// to approve it. Approvals are recorded in persistentState, keyed by the
+// source's remote URL and commit. If readOnly is true, or in dry run mode,
+// then persistentState cannot record an approval so the user is not prompted.
+func (c *Config) ensureSourceTrusted(persistentState chezmoi.PersistentState, readOnly bool) error {
+	sourceID, diagnostics, err := c.findUntrustedCommands(persistentState)
+	if err != nil {
+		return err
+	}
+	if len(diagnostics) == 0 {
+		c.trustedSourceID = sourceID
+		return nil
+	}
+
+	fmt.Fprintf(c.Stdout, "%s can run commands:\n", sourceID)
+	for _, d := range diagnostics {
+		if relPath, err := filepath.Rel(c.SourceDir, d.Path); err == nil {
+			d.Path = relPath
+		}
+		fmt.Fprintf(c.Stdout, "  %s\n", d)
+	}
+	untrustedErr := fmt.Errorf("%s: source state not trusted, approve it with chezmoi apply or use --no-scripts", sourceID)
+	if readOnly || c.DryRun {
+		return untrustedErr
+	}
+	choice, err := c.prompt("Trust this source state", "yn")
+	if err != nil {
+		return untrustedErr
+	}
+	if choice != 'y' {
+		return errors.New("source state not trusted")
+	}
+
+	trustStateData, err := json.Marshal(&trustState{
+		TrustedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := persistentState.Set(c.trustStateBucket, []byte(sourceID), trustStateData); err != nil {
+		return err
+	}
+	c.trustedSourceID = sourceID
+	return nil
+}
+
+// ensureRenderTrusted returns nil if the source state can run commands, without
+// prompting. It is used by commands that render the target state without
+// applying it.
+func (c *Config) ensureRenderTrusted() error {
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+	return c.ensureSourceTrusted(persistentState, true)
+}
+
+// isSourceApproved returns true if the source state may call template functions
+// that run commands: it has no remote, it has been approved, or it was checked
+// by ensureSourceTrusted earlier. Any error is treated as the source state not
+// being approved.
+func (c *Config) isSourceApproved() bool {
+	sourceID, err := c.getSourceID()
+	switch {
+	case err != nil:
+		return false
+	case sourceID == "" || sourceID == c.trustedSourceID:
+		return true
+	}
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		return false
+	}
+	defer persistentState.Close()
+	trustStateData, err := persistentState.Get(c.trustStateBucket, []byte(sourceID))
+	return err == nil && trustStateData != nil
+}
+
+// findUntrustedCommands returns the source ID and the commands that the source
+// state can run, or no commands if the source state is trusted.
+func (c *Config) findUntrustedCommands(persistentState chezmoi.PersistentState) (string, []*chezmoi.Diagnostic, error) {
+	if c.noScripts {
+		return "", nil, nil
+	}
+	sourceID, err := c.getSourceID()
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot identify source state: %w", err)
+	}
+	if sourceID == "" {
+		return "", nil, nil
+	}
+	trustStateData, err := persistentState.Get(c.trustStateBucket, []byte(sourceID))
+	if err != nil {
+		return "", nil, err
+	}
+	if trustStateData != nil {
+		return sourceID, nil, nil
+	}
+
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithTemplateFuncs(c.templateFuncs),
+		chezmoi.WithTemplateOptions(c.Template.Options),
+	)
+	fs, err := c.getSourceFS()
+	if err != nil {
+		return "", nil, err
+	}
+	diagnostics, err := ts.FindCommands(fs, c.execTemplateFuncNames)
+	if err != nil {
+		return "", nil, err
+	}
+	return sourceID, diagnostics, nil
+}
+
+// getSourceID returns the ID under which approval of the source state is
+// recorded. For git and Mercurial this is the remote URL and commit. It returns
+// an empty string if the source directory is not in a repository or the
+// repository has no remote, as then the source state was not cloned from
+// elsewhere. Other VCSes cannot be queried, so their ID is the source directory
+// and a hash of its contents.
+func (c *Config) getSourceID() (string, error) {
+	if _, err := c.fs.Stat(c.SourceDir); os.IsNotExist(err) {
+		return "", nil
+	}
+	vcs, err := c.getVCS()
+	if err != nil {
+		return c.getSourceContentsID()
+	}
+	var metadataDirName string
+	var remoteArgs, commitArgs []string
+	switch vcs.(type) {
+	case builtinGitVCS:
+		rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+		if err != nil {
+			return "", err
+		}
+		remote, commit, err := builtinGitVCS{}.SourceID(rawSourceDir, c.getSourceRevision())
+		if err != nil || remote == "" {
+			return "", err
+		}
+		return remote + "@" + commit, nil
+	case gitVCS:
+		metadataDirName = ".git"
+		remoteArgs = []string{"config", "--get", "remote.origin.url"}
+		commitArgs = []string{"rev-parse", "--verify", c.getSourceRevision() + "^{commit}"}
+	case hgVCS:
+		metadataDirName = ".hg"
+		remoteArgs = []string{"paths", "default"}
+		commitArgs = []string{"log", "--rev", ".", "--template", "{node}"}
+	default:
+		return c.getSourceContentsID()
+	}
+	if inRepo, err := c.sourceDirInRepo(metadataDirName); err != nil || !inRepo {
+		return "", err
+	}
+	remote, err := c.sourceVCSOutput(remoteArgs...)
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// Both git and hg exit with code 1 if there is no remote.
+		return "", nil
+	case err != nil:
+		return "", err
+	case remote == "":
+		return "", nil
+	}
+	commit, err := c.sourceVCSOutput(commitArgs...)
+	if err != nil {
+		return "", err
+	}
+	return remote + "@" + commit, nil
+}
+
+// getSourceContentsID returns a source ID made from the source directory and a
+// hash of the contents that chezmoi reads from it. Other hidden directories,
+// for example a VCS's metadata, are skipped.
+func (c *Config) getSourceContentsID() (string, error) {
+	fs, err := c.getSourceFS()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if err := vfs.Walk(fs, c.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(c.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		var contents []byte
+		switch {
+		case info.IsDir():
+			if path != c.SourceDir && strings.HasPrefix(info.Name(), ".") && info.Name() != ".chezmoitemplates" {
+				return filepath.SkipDir
+			}
+			return nil
+		case info.Mode().IsRegular():
+			contents, err = fs.ReadFile(path)
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			var linkname string
+			linkname, err = fs.Readlink(path)
+			contents = []byte(linkname)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", filepath.ToSlash(relPath), info.Mode(), len(contents))
+		_, err = h.Write(contents)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	return c.SourceDir + "@" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceDirInRepo returns true if the source directory or any of its parents
+// contains metadataDirName.
+func (c *Config) sourceDirInRepo(metadataDirName string) (bool, error) {
+	for dir := c.SourceDir; ; dir = filepath.Dir(dir) {
+		switch _, err := c.fs.Lstat(filepath.Join(dir, metadataDirName)); {
+		case err == nil:
+			return true, nil
+		case !os.IsNotExist(err):
+			return false, err
+		}
+		if parentDir := filepath.Dir(dir); parentDir == dir {
+			return false, nil
+		}
+	}
+}
+
+// sourceVCSOutput returns the trimmed output of the source VCS command with
+// args in the source directory. The command is not passed to the mutator as it
+// is only used to query the source directory.
+func (c *Config) sourceVCSOutput(args ...string) (string, error) {
+	dir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return "", err
+	}
+	//nolint:gosec
+	cmd := exec.Command(c.SourceVCS.Command, args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -1,14 +1,37 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type updateCmdConfig struct {
-	apply bool
+	VerifySignatures   bool
+	VerifyAllCommits   bool
+	AllowedSignersFile string
+	KeyringFile        string
+	apply              bool
+	diff               bool
+	prompt             bool
+}
+
+// A targetSnapshot is the rendered state of a target, used to show the
+// changes made to the target state by update.
+type targetSnapshot struct {
+	contents []byte
+	perm     os.FileMode
 }
 
 var updateCmd = &cobra.Command{
@@ -25,14 +48,189 @@ func init() {
 	rootCmd.AddCommand(updateCmd)
 
 	persistentFlags := updateCmd.PersistentFlags()
-	persistentFlags.BoolVarP(&config.update.apply, "apply", "a", true, "apply after pulling")
+	persistentFlags.BoolVarP(&config.Update.apply, "apply", "a", true, "apply after pulling")
+	persistentFlags.BoolVarP(&config.Update.diff, "diff", "d", false, "print the changes to the target state")
+	persistentFlags.BoolVarP(&config.Update.prompt, "prompt", "p", false, "prompt before applying (implies --diff)")
+
+	addEntryFilterFlags(updateCmd)
 }
 
 func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
+	if c.Update.prompt {
+		c.Update.diff = true
+	}
+
 	vcs, err := c.getVCS()
 	if err != nil {
 		return err
 	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if err := c.ensureSourceTrusted(persistentState, false); err != nil {
+		return err
+	}
+	// If the current source state cannot be rendered then treat it as empty,
+	// so that update can still pull a fix.
+	var prevSnapshots map[string]targetSnapshot
+	var prevMinVersion *semver.Version
+	if ts, err := c.getTargetState(nil); err == nil {
+		prevMinVersion = ts.MinVersion
+		prevSnapshots, _ = snapshotTargetState(ts)
+	}
+
+	prevHead, err := c.pullSource(vcs)
+	if err != nil {
+		return err
+	}
+
+	// Once applying has started the destination directory reflects the new
+	// source state, so only restore the previous HEAD before then.
+	if err := c.showTargetStateChanges(persistentState, prevSnapshots, prevMinVersion); err != nil {
+		return c.restoreSourceHead(prevHead, err)
+	}
+
+	if !c.Update.apply {
+		return nil
+	}
+	if c.Update.prompt {
+		choice, err := c.prompt("Apply changes", "yn")
+		if err != nil {
+			return err
+		}
+		if choice != 'y' {
+			return nil
+		}
+	}
+	return c.applyArgs(nil, persistentState, false)
+}
+
+// showTargetStateChanges prints the changes to the target state since it was
+// prevSnapshots, the scripts that apply would run, and any new minimum
+// version.
+func (c *Config) showTargetStateChanges(persistentState chezmoi.PersistentState, prevSnapshots map[string]targetSnapshot, prevMinVersion *semver.Version) error {
+	if err := c.ensureSourceTrusted(persistentState, false); err != nil {
+		return err
+	}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	snapshots, err := snapshotTargetState(ts)
+	if err != nil {
+		return err
+	}
+
+	diffWriter := ioutil.Discard
+	if c.Update.diff {
+		diffWriter = c.Stdout
+	}
+	changes, err := c.diffTargetSnapshots(diffWriter, prevSnapshots, snapshots)
+	if err != nil {
+		return err
+	}
+
+	var scriptNames []string
+	applyOptions := &chezmoi.ApplyOptions{
+		Ignore:            ts.Ignore,
+		NoScripts:         c.noScripts,
+		PersistentState:   persistentState,
+		ScriptStateBucket: c.scriptStateBucket,
+	}
+	for _, script := range findScripts(ts.Entries) {
+		needsRun, err := script.NeedsRun(applyOptions)
+		if err != nil {
+			return err
+		}
+		if needsRun {
+			scriptNames = append(scriptNames, script.TargetName())
+		}
+	}
+	sort.Strings(scriptNames)
+
+	if len(changes) != 0 {
+		fmt.Fprintln(c.Stdout, "targets changed:")
+		for _, change := range changes {
+			fmt.Fprintf(c.Stdout, "  %s\n", change)
+		}
+	}
+	if len(scriptNames) != 0 {
+		fmt.Fprintln(c.Stdout, "scripts to run:")
+		for _, scriptName := range scriptNames {
+			fmt.Fprintf(c.Stdout, "  %s\n", scriptName)
+		}
+	}
+	if ts.MinVersion != nil && (prevMinVersion == nil || !ts.MinVersion.Equal(*prevMinVersion)) {
+		fmt.Fprintf(c.Stdout, "source state now requires chezmoi version %s or later\n", ts.MinVersion)
+	}
+	return nil
+}
+
+// diffTargetSnapshots writes the differences between prevSnapshots and
+// snapshots to w and returns a description of each changed target.
+func (c *Config) diffTargetSnapshots(w io.Writer, prevSnapshots, snapshots map[string]targetSnapshot) ([]string, error) {
+	targetNames := make([]string, 0, len(snapshots))
+	for targetName := range prevSnapshots {
+		if _, ok := snapshots[targetName]; !ok {
+			targetNames = append(targetNames, targetName)
+		}
+	}
+	for targetName := range snapshots {
+		targetNames = append(targetNames, targetName)
+	}
+	sort.Strings(targetNames)
+
+	var changes []string
+	for _, targetName := range targetNames {
+		prevSnapshot, prevOK := prevSnapshots[targetName]
+		snapshot, ok := snapshots[targetName]
+		switch {
+		case !prevOK:
+			changes = append(changes, "added "+targetName)
+		case !ok:
+			changes = append(changes, "removed "+targetName)
+		case !bytes.Equal(prevSnapshot.contents, snapshot.contents) || prevSnapshot.perm != snapshot.perm:
+			changes = append(changes, "modified "+targetName)
+		default:
+			continue
+		}
+		if prevSnapshot.perm != snapshot.perm && prevOK && ok {
+			fmt.Fprintf(w, "%s: mode %03o => %03o\n", targetName, prevSnapshot.perm, snapshot.perm)
+		}
+		if !bytes.Equal(prevSnapshot.contents, snapshot.contents) {
+			if err := chezmoi.WriteUnifiedDiff(w, "a/"+targetName, "b/"+targetName, prevSnapshot.contents, snapshot.contents, c.colored); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
+
+// pullSource pulls changes into the source directory with vcs, verifying
+// signatures if configured and updating any submodules. If the source VCS is
+// git then it returns the HEAD before pulling, which is restored if any step
+// fails.
+func (c *Config) pullSource(vcs VCS) (string, error) {
+	if builtinVCS, ok := vcs.(builtinGitVCS); ok {
+		if c.SourceVCS.Pull != nil {
+			return "", fmt.Errorf("%s: sourceVCS.pull not supported", c.SourceVCS.Command)
+		}
+		if c.Update.VerifySignatures {
+			return "", fmt.Errorf("%s: update.verifySignatures requires git", c.SourceVCS.Command)
+		}
+		rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+		if err != nil {
+			return "", err
+		}
+		return "", c.runBuiltinVCS(func() error {
+			return builtinVCS.Pull(rawSourceDir)
+		}, "pull", "--ff-only")
+	}
+
 	var pullArgs []string
 	if c.SourceVCS.Pull != nil {
 		switch v := c.SourceVCS.Pull.(type) {
@@ -41,29 +239,228 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 		case []string:
 			pullArgs = v
 		default:
-			return fmt.Errorf("sourceVCS.pull: cannot parse value")
+			return "", fmt.Errorf("sourceVCS.pull: cannot parse value")
 		}
 	} else {
 		pullArgs = vcs.PullArgs()
 	}
 	if pullArgs == nil {
-		return fmt.Errorf("%s: pull not supported", c.SourceVCS.Command)
+		return "", fmt.Errorf("%s: pull not supported", c.SourceVCS.Command)
+	}
+
+	_, isGit := vcs.(gitVCS)
+	// In dry run mode nothing is pulled, so there is nothing to verify.
+	verify := c.Update.VerifySignatures && !c.DryRun
+	if verify && !isGit {
+		return "", fmt.Errorf("%s: update.verifySignatures requires git", c.SourceVCS.Command)
+	}
+	var prevHead string
+	if isGit {
+		var err error
+		prevHead, err = c.sourceVCSOutput("rev-parse", "HEAD")
+		// A repository without any commits has no HEAD to restore, but its
+		// signatures cannot be verified either.
+		if err != nil && verify {
+			return "", err
+		}
 	}
 
 	if err := c.run(c.SourceDir, c.SourceVCS.Command, pullArgs...); err != nil {
+		return "", c.restoreSourceHead(prevHead, err)
+	}
+
+	if verify {
+		if err := c.verifySourceSignatures(prevHead); err != nil {
+			return "", c.restoreSourceHead(prevHead, err)
+		}
+	}
+
+	if err := c.updateSourceSubmodules(vcs); err != nil {
+		return "", c.restoreSourceHead(prevHead, err)
+	}
+
+	return prevHead, nil
+}
+
+// restoreSourceHead restores the source directory to prevHead after err, first
+// aborting any rebase or merge left by a failed pull. Local changes are kept.
+// It returns err annotated with the result. If prevHead is empty then it
+// returns err unchanged.
+func (c *Config) restoreSourceHead(prevHead string, err error) error {
+	if prevHead == "" {
 		return err
 	}
+	if restoreErr := c.abortSourcePull(); restoreErr != nil {
+		return fmt.Errorf("%w, and restoring %s failed: %v", err, prevHead, restoreErr)
+	}
+	if restoreErr := c.run(c.SourceDir, c.SourceVCS.Command, "reset", "--keep", prevHead); restoreErr != nil {
+		return fmt.Errorf("%w, and restoring %s failed: %v", err, prevHead, restoreErr)
+	}
+	return fmt.Errorf("%w, restored %s", err, prevHead)
+}
 
-	if c.update.apply {
-		persistentState, err := c.getPersistentState(nil)
+// abortSourcePull aborts any rebase or merge in progress in the source
+// directory.
+func (c *Config) abortSourcePull() error {
+	for _, abort := range []struct {
+		gitPath string
+		args    []string
+	}{
+		{gitPath: "rebase-merge", args: []string{"rebase", "--abort"}},
+		{gitPath: "rebase-apply", args: []string{"rebase", "--abort"}},
+		{gitPath: "MERGE_HEAD", args: []string{"merge", "--abort"}},
+	} {
+		path, err := c.sourceVCSOutput("rev-parse", "--git-path", abort.gitPath)
 		if err != nil {
 			return err
 		}
-		defer persistentState.Close()
-		if err := c.applyArgs(nil, persistentState); err != nil {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.SourceDir, path)
+		}
+		if _, err := c.fs.Stat(path); err == nil {
+			return c.run(c.SourceDir, c.SourceVCS.Command, abort.args...)
+		}
+	}
+	return nil
+}
+
+// updateSourceSubmodules initializes and updates any submodules in the source
+// directory with vcs.
+func (c *Config) updateSourceSubmodules(vcs VCS) error {
+	updateSubmodulesArgs := vcs.UpdateSubmodulesArgs()
+	if updateSubmodulesArgs == nil {
+		return nil
+	}
+	if _, err := c.fs.Stat(filepath.Join(c.SourceDir, ".gitmodules")); err != nil {
+		return nil
+	}
+	return c.run(c.SourceDir, c.SourceVCS.Command, updateSubmodulesArgs...)
+}
+
+// findScripts returns all scripts in entries and their subdirectories.
+func findScripts(entries map[string]chezmoi.Entry) []*chezmoi.Script {
+	var scripts []*chezmoi.Script
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			scripts = append(scripts, findScripts(entry.Entries)...)
+		case *chezmoi.Script:
+			scripts = append(scripts, entry)
+		}
+	}
+	return scripts
+}
+
+// snapshotTargetState returns the rendered state of every target in ts, except
+// ignored targets, keyed by target name.
+func snapshotTargetState(ts *chezmoi.TargetState) (map[string]targetSnapshot, error) {
+	snapshots := make(map[string]targetSnapshot)
+	for _, entry := range ts.AllEntries() {
+		if ignoredTarget(ts, entry.TargetName()) {
+			continue
+		}
+		snapshot, err := snapshotEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[entry.TargetName()] = snapshot
+	}
+	return snapshots, nil
+}
+
+// snapshotEntry returns the rendered state of entry.
+func snapshotEntry(entry chezmoi.Entry) (targetSnapshot, error) {
+	var snapshot targetSnapshot
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		snapshot.perm = entry.Perm
+	case *chezmoi.File:
+		contents, err := entry.Contents()
+		if err != nil {
+			return targetSnapshot{}, err
+		}
+		snapshot.contents = contents
+		snapshot.perm = entry.Perm
+	case *chezmoi.Script:
+		contents, err := entry.Contents()
+		if err != nil {
+			return targetSnapshot{}, err
+		}
+		snapshot.contents = contents
+	case *chezmoi.Symlink:
+		linkname, err := entry.Linkname()
+		if err != nil {
+			return targetSnapshot{}, err
+		}
+		snapshot.contents = []byte(linkname)
+	}
+	return snapshot, nil
+}
+
+// verifySourceSignatures verifies the signature of HEAD in the source
+// directory and, if update.verifyAllCommits is set, of every commit since
+// prevHead.
+func (c *Config) verifySourceSignatures(prevHead string) error {
+	revs := []string{"HEAD"}
+	if c.Update.VerifyAllCommits {
+		output, err := c.sourceVCSOutput("rev-list", prevHead+"..HEAD")
+		if err != nil {
+			return err
+		}
+		if output != "" {
+			revs = strings.Split(output, "\n")
+		}
+	}
+	return c.verifySourceRevisionSignatures(revs)
+}
+
+// verifySourceRevisionSignatures verifies the signatures of revs in the source
+// directory with the keys configured in update.
+func (c *Config) verifySourceRevisionSignatures(revs []string) error {
+	var args, env []string
+	if c.Update.AllowedSignersFile != "" || c.Update.KeyringFile != "" {
+		// Only accept signatures from the configured keys. If only one of the
+		// files is set then the other signature format is checked against an
+		// empty set of keys, so that git's own configuration and the user's
+		// default GnuPG keyring are never used.
+		allowedSignersFile := c.Update.AllowedSignersFile
+		if allowedSignersFile == "" {
+			allowedSignersFile = os.DevNull
+		}
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+allowedSignersFile)
+		gnupgHome, err := ioutil.TempDir("", "chezmoi-gnupg")
+		if err != nil {
 			return err
 		}
+		defer os.RemoveAll(gnupgHome)
+		if c.Update.KeyringFile != "" {
+			//nolint:gosec
+			cmd := exec.Command(c.GPG.Command, "--homedir", gnupgHome, "--batch", "--quiet", "--import", c.Update.KeyringFile)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("%s: %w: %s", c.Update.KeyringFile, err, strings.TrimSpace(string(output)))
+			}
+		}
+		args = append(args, "-c", "gpg.program="+c.GPG.Command)
+		env = append(env, "GNUPGHOME="+gnupgHome)
 	}
 
+	dir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return err
+	}
+	for _, rev := range revs {
+		//nolint:gosec
+		cmd := exec.Command(c.SourceVCS.Command, append(append([]string{}, args...), "verify-commit", rev)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			message := strings.TrimSpace(string(output))
+			if message == "" {
+				// git verify-commit prints nothing for unsigned commits.
+				message = "not signed"
+			}
+			return fmt.Errorf("%s: signature verification failed: %s", rev, message)
+		}
+	}
 	return nil
 }
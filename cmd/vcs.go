@@ -12,11 +12,13 @@ type VCS interface {
 	PullArgs() []string
 	PushArgs() []string
 	StatusArgs() []string
+	UpdateSubmodulesArgs() []string
 	VersionArgs() []string
 	VersionRegexp() *regexp.Regexp
 }
 
 var vcses = map[string]VCS{
-	"git": gitVCS{},
-	"hg":  hgVCS{},
+	builtinVCSCommand: builtinGitVCS{},
+	"git":             gitVCS{},
+	"hg":              hgVCS{},
 }
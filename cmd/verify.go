@@ -20,6 +20,7 @@ func init() {
 	rootCmd.AddCommand(verifyCmd)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(verifyCmd, 1)
+	addEntryFilterFlags(verifyCmd)
 }
 
 func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
@@ -34,7 +35,7 @@ func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	if err := c.applyArgs(args, persistentState); err != nil {
+	if err := c.applyArgs(args, persistentState, true); err != nil {
 		return err
 	}
 	if mutator.Mutated() {
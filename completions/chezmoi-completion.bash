@@ -363,6 +363,7 @@ _chezmoi_add()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -389,6 +390,16 @@ _chezmoi_apply()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -401,6 +412,46 @@ _chezmoi_apply()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_apply-patch()
+{
+    last_command="chezmoi_apply-patch"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -427,6 +478,18 @@ _chezmoi_archive()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--data-file=")
+    two_word_flags+=("--data-file")
+    flags_with_completion+=("--data-file")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--output=")
     two_word_flags+=("--output")
     flags_with_completion+=("--output")
@@ -434,6 +497,10 @@ _chezmoi_archive()
     two_word_flags+=("-o")
     flags_with_completion+=("-o")
     flags_completion+=("_filedir")
+    flags+=("--override-data=")
+    two_word_flags+=("--override-data")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -446,6 +513,7 @@ _chezmoi_archive()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -472,6 +540,14 @@ _chezmoi_cat()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--data-file=")
+    two_word_flags+=("--data-file")
+    flags_with_completion+=("--data-file")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
+    flags+=("--override-data=")
+    two_word_flags+=("--override-data")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -484,6 +560,7 @@ _chezmoi_cat()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -522,6 +599,7 @@ _chezmoi_cd()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -560,6 +638,7 @@ _chezmoi_chattr()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -608,6 +687,7 @@ _chezmoi_completion()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -637,9 +717,15 @@ _chezmoi_data()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--data-file=")
+    two_word_flags+=("--data-file")
+    flags_with_completion+=("--data-file")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--override-data=")
+    two_word_flags+=("--override-data")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -652,6 +738,7 @@ _chezmoi_data()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -678,10 +765,22 @@ _chezmoi_diff()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--between")
+    flags+=("--dir-diff")
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--no-pager")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -694,6 +793,7 @@ _chezmoi_diff()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -732,6 +832,7 @@ _chezmoi_docs()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -770,6 +871,7 @@ _chezmoi_doctor()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -796,11 +898,27 @@ _chezmoi_dump()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--data-file=")
+    two_word_flags+=("--data-file")
+    flags_with_completion+=("--data-file")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--override-data=")
+    two_word_flags+=("--override-data")
     flags+=("--recursive")
     flags+=("-r")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -813,6 +931,7 @@ _chezmoi_dump()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -857,6 +976,7 @@ _chezmoi_edit()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -895,6 +1015,7 @@ _chezmoi_edit-config()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -921,11 +1042,23 @@ _chezmoi_execute-template()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--data-file=")
+    two_word_flags+=("--data-file")
+    flags_with_completion+=("--data-file")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
     flags+=("--init")
     flags+=("-i")
     flags+=("--output=")
     two_word_flags+=("--output")
     two_word_flags+=("-o")
+    flags+=("--override-data=")
+    two_word_flags+=("--override-data")
+    flags+=("--promptBool=")
+    two_word_flags+=("--promptBool")
+    flags+=("--promptChoice=")
+    two_word_flags+=("--promptChoice")
+    flags+=("--promptInt=")
+    two_word_flags+=("--promptInt")
     flags+=("--promptString=")
     two_word_flags+=("--promptString")
     two_word_flags+=("-p")
@@ -941,6 +1074,7 @@ _chezmoi_execute-template()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -979,6 +1113,7 @@ _chezmoi_forget()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1017,6 +1152,7 @@ _chezmoi_git()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1055,6 +1191,51 @@ _chezmoi_hg()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_history()
+{
+    last_command="chezmoi_history"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--max-count=")
+    two_word_flags+=("--max-count")
+    flags+=("--no-pager")
+    flags+=("--source-ref=")
+    two_word_flags+=("--source-ref")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1099,6 +1280,7 @@ _chezmoi_import()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1126,6 +1308,62 @@ _chezmoi_init()
     flags_completion=()
 
     flags+=("--apply")
+    flags+=("--branch=")
+    two_word_flags+=("--branch")
+    flags+=("--depth=")
+    two_word_flags+=("--depth")
+    two_word_flags+=("-d")
+    flags+=("--one-shot")
+    flags+=("--promptBool=")
+    two_word_flags+=("--promptBool")
+    flags+=("--promptChoice=")
+    two_word_flags+=("--promptChoice")
+    flags+=("--promptDefaults")
+    flags+=("--promptInt=")
+    two_word_flags+=("--promptInt")
+    flags+=("--promptString=")
+    two_word_flags+=("--promptString")
+    flags+=("--regenerate-config")
+    flags+=("--ssh")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_lint()
+{
+    last_command="chezmoi_lint"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1138,6 +1376,7 @@ _chezmoi_init()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1164,6 +1403,11 @@ _chezmoi_managed()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
     flags+=("--include=")
     two_word_flags+=("--include")
     two_word_flags+=("-i")
@@ -1179,6 +1423,7 @@ _chezmoi_managed()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1217,6 +1462,7 @@ _chezmoi_merge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1257,6 +1503,7 @@ _chezmoi_purge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1297,6 +1544,7 @@ _chezmoi_remove()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1335,6 +1583,7 @@ _chezmoi_secret_bitwarden()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1373,6 +1622,7 @@ _chezmoi_secret_generic()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1411,6 +1661,7 @@ _chezmoi_secret_gopass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1449,6 +1700,7 @@ _chezmoi_secret_keepassxc()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1487,6 +1739,7 @@ _chezmoi_secret_keyring_get()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
@@ -1531,6 +1784,7 @@ _chezmoi_secret_keyring_set()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
@@ -1579,6 +1833,7 @@ _chezmoi_secret_keyring()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1619,6 +1874,7 @@ _chezmoi_secret_lastpass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1657,6 +1913,7 @@ _chezmoi_secret_onepassword()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1695,6 +1952,7 @@ _chezmoi_secret_pass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1733,6 +1991,7 @@ _chezmoi_secret_vault()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1780,6 +2039,7 @@ _chezmoi_secret()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1818,6 +2078,7 @@ _chezmoi_source()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1856,6 +2117,140 @@ _chezmoi_source-path()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_source-status()
+{
+    last_command="chezmoi_source-status"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_sync()
+{
+    last_command="chezmoi_sync"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_test()
+{
+    last_command="chezmoi_test"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--update")
+    flags+=("-u")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1894,6 +2289,7 @@ _chezmoi_unmanaged()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1922,6 +2318,18 @@ _chezmoi_update()
 
     flags+=("--apply")
     flags+=("-a")
+    flags+=("--diff")
+    flags+=("-d")
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--prompt")
+    flags+=("-p")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1934,6 +2342,7 @@ _chezmoi_update()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1983,6 +2392,7 @@ _chezmoi_upgrade()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2009,6 +2419,14 @@ _chezmoi_verify()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--glob=")
+    two_word_flags+=("--glob")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2021,6 +2439,7 @@ _chezmoi_verify()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2046,6 +2465,7 @@ _chezmoi_root_command()
         aliashash["manage"]="add"
     fi
     commands+=("apply")
+    commands+=("apply-patch")
     commands+=("archive")
     commands+=("cat")
     commands+=("cd")
@@ -2066,8 +2486,10 @@ _chezmoi_root_command()
     fi
     commands+=("git")
     commands+=("hg")
+    commands+=("history")
     commands+=("import")
     commands+=("init")
+    commands+=("lint")
     commands+=("managed")
     commands+=("merge")
     commands+=("purge")
@@ -2079,6 +2501,9 @@ _chezmoi_root_command()
     commands+=("secret")
     commands+=("source")
     commands+=("source-path")
+    commands+=("source-status")
+    commands+=("sync")
+    commands+=("test")
     commands+=("unmanaged")
     commands+=("update")
     commands+=("upgrade")
@@ -2102,6 +2527,7 @@ _chezmoi_root_command()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--no-scripts")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
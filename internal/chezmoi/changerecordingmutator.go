@@ -0,0 +1,98 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A ChangeRecordingMutator wraps another Mutator and records the targets
+// changed by its mutating methods.
+type ChangeRecordingMutator struct {
+	m       Mutator
+	prefix  string
+	changed map[string]struct{}
+}
+
+// NewChangeRecordingMutator returns a new ChangeRecordingMutator that records
+// changes to targets in destDir.
+func NewChangeRecordingMutator(m Mutator, destDir string) *ChangeRecordingMutator {
+	return &ChangeRecordingMutator{
+		m:       m,
+		prefix:  destDir + string(filepath.Separator),
+		changed: make(map[string]struct{}),
+	}
+}
+
+// Changed returns the sorted names of all changed targets, relative to the
+// destination directory.
+func (m *ChangeRecordingMutator) Changed() []string {
+	changed := make([]string, 0, len(m.changed))
+	for name := range m.changed {
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *ChangeRecordingMutator) Chmod(name string, mode os.FileMode) error {
+	m.record(name)
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *ChangeRecordingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *ChangeRecordingMutator) Mkdir(name string, perm os.FileMode) error {
+	m.record(name)
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *ChangeRecordingMutator) RemoveAll(name string) error {
+	m.record(name)
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *ChangeRecordingMutator) Rename(oldpath, newpath string) error {
+	m.record(oldpath)
+	m.record(newpath)
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *ChangeRecordingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *ChangeRecordingMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *ChangeRecordingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	m.record(name)
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *ChangeRecordingMutator) WriteSymlink(oldname, newname string) error {
+	m.record(newname)
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// record records that name was changed, if it is in the destination
+// directory.
+func (m *ChangeRecordingMutator) record(name string) {
+	if strings.HasPrefix(name, m.prefix) {
+		m.changed[strings.TrimPrefix(name, m.prefix)] = struct{}{}
+	}
+}
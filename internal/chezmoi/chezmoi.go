@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	vfs "github.com/twpayne/go-vfs"
 )
@@ -39,9 +40,14 @@ type ApplyOptions struct {
 	DestDir           string
 	DryRun            bool
 	Ignore            func(string) bool
+	NoScripts         bool
 	PersistentState   PersistentState
 	Remove            bool
+	ScriptEnv         []string
+	ScriptError       func(*Script, error) error
+	ScriptLog         io.Writer
 	ScriptStateBucket []byte
+	ScriptTimeout     time.Duration
 	Stdout            io.Writer
 	Umask             os.FileMode
 	Verbose           bool
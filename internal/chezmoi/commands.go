@@ -0,0 +1,192 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// FindCommands returns a Diagnostic for every script, trigger, and call to
+// any of funcNames in the source state in fs, without executing any
+// templates. Encrypted templates cannot be checked and are always reported.
+// Templates that cannot be parsed are not reported as they cannot be
+// executed.
+func (ts *TargetState) FindCommands(fs vfs.FS, funcNames []string) ([]*Diagnostic, error) {
+	var diagnostics []*Diagnostic
+	var templatePaths []string
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == ts.SourceDir {
+			return nil
+		}
+		name := info.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+			switch {
+			case name == templatesDirName:
+				if err := vfs.Walk(fs, path, func(path string, info os.FileInfo, err error) error {
+					if err == nil && info.Mode().IsRegular() {
+						templatePaths = append(templatePaths, path)
+					}
+					return err
+				}); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			case name == triggersName:
+				triggerDiagnostics, err := findTriggers(fs, path)
+				if err != nil {
+					return err
+				}
+				diagnostics = append(diagnostics, triggerDiagnostics...)
+				templatePaths = append(templatePaths, path)
+			case name == ignoreName || name == removeName:
+				templatePaths = append(templatePaths, path)
+			case strings.HasPrefix(name, ".chezmoi.") && strings.HasSuffix(name, TemplateSuffix):
+				templatePaths = append(templatePaths, path)
+			case info.IsDir():
+				return filepath.SkipDir
+			}
+		case !info.Mode().IsRegular():
+		case strings.HasPrefix(name, runPrefix):
+			diagnostics = append(diagnostics, &Diagnostic{
+				Path:    path,
+				Line:    1,
+				Col:     1,
+				Message: "script",
+			})
+			if ParseScriptAttributes(name).Template {
+				templatePaths = append(templatePaths, path)
+			}
+		case strings.HasSuffix(name, TemplateSuffix):
+			if ParseFileAttributes(name).Encrypted {
+				diagnostics = append(diagnostics, &Diagnostic{
+					Path:    path,
+					Line:    1,
+					Col:     1,
+					Message: "encrypted template cannot be checked",
+				})
+			} else {
+				templatePaths = append(templatePaths, path)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	funcNameSet := make(map[string]bool)
+	for _, funcName := range funcNames {
+		funcNameSet[funcName] = true
+	}
+	for _, path := range templatePaths {
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		td, data, err := parseTemplateDirective(data)
+		if err != nil {
+			continue
+		}
+		tmpl, err := td.apply(ts.newTemplate(path)).Parse(string(data))
+		if err != nil {
+			continue
+		}
+		for _, t := range tmpl.Templates() {
+			if t.Tree == nil || t.Tree.Root == nil {
+				continue
+			}
+			templateFuncCalls(t.Tree.Root, funcNameSet, func(node *parse.IdentifierNode) {
+				line, col := lineCol(string(data), int(node.Position()))
+				diagnostics = append(diagnostics, &Diagnostic{
+					Path:    path,
+					Line:    td.fileLine(line),
+					Col:     col,
+					Message: "calls " + node.Ident,
+				})
+			})
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Path < diagnostics[j].Path
+	})
+	return diagnostics, nil
+}
+
+// findTriggers returns a Diagnostic for every trigger defined in the
+// .chezmoitriggers file at path.
+func findTriggers(fs vfs.FS, path string) ([]*Diagnostic, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var diagnostics []*Diagnostic
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		diagnostics = append(diagnostics, &Diagnostic{
+			Path:    path,
+			Line:    lineNumber,
+			Col:     1,
+			Message: "trigger: " + text,
+		})
+	}
+	return diagnostics, s.Err()
+}
+
+// templateFuncCalls calls f for every identifier in node that is in
+// funcNames.
+func templateFuncCalls(node parse.Node, funcNames map[string]bool, f func(*parse.IdentifierNode)) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		templateFuncCalls(node.Pipe, funcNames, f)
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			templateFuncCalls(arg, funcNames, f)
+		}
+	case *parse.IdentifierNode:
+		if funcNames[node.Ident] {
+			f(node)
+		}
+	case *parse.IfNode:
+		templateFuncCalls(node.Pipe, funcNames, f)
+		templateFuncCalls(node.List, funcNames, f)
+		templateFuncCalls(node.ElseList, funcNames, f)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			templateFuncCalls(n, funcNames, f)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			templateFuncCalls(cmd, funcNames, f)
+		}
+	case *parse.RangeNode:
+		templateFuncCalls(node.Pipe, funcNames, f)
+		templateFuncCalls(node.List, funcNames, f)
+		templateFuncCalls(node.ElseList, funcNames, f)
+	case *parse.TemplateNode:
+		templateFuncCalls(node.Pipe, funcNames, f)
+	case *parse.WithNode:
+		templateFuncCalls(node.Pipe, funcNames, f)
+		templateFuncCalls(node.List, funcNames, f)
+		templateFuncCalls(node.ElseList, funcNames, f)
+	}
+}
@@ -0,0 +1,58 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetStateFindCommands(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoi.toml.tmpl":               `email = {{ output "git" }}`,
+			".chezmoiignore":                   "{{ if output }}README.md{{ end }}\n",
+			".chezmoitemplates/partial":        "{{ define \"x\" }}{{ secret }}{{ end }}",
+			".chezmoitriggers":                 "# comment\n.tmux.conf tmux source-file ~/.tmux.conf\n",
+			".git/dot_hook.tmpl":               "{{ output }}",
+			"dot_bashrc.tmpl":                  "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n{{ output }}\n[[ .name | output ]]\n",
+			"dot_invalid.tmpl":                 "{{ output",
+			"dot_profile":                      "{{ output }}",
+			"encrypted_dot_netrc.tmpl":         "ciphertext",
+			"run_once_install.sh.tmpl":         "#!/bin/sh\n{{ if true }}\n{{ (secret \"x\") }}\n{{ end }}\n",
+			"dot_config/run_reload.sh":         "#!/bin/sh\n",
+			"dot_config/dot_gitconfig.tmpl":    "{{ include \"output\" }}",
+			"dot_config/symlink_dot_link.tmpl": "{{ secret }}",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateFuncs(map[string]interface{}{
+			"include": func(string) string { return "" },
+			"output":  func(...string) string { return "" },
+			"secret":  func(...string) string { return "" },
+		}),
+	)
+	diagnostics, err := ts.FindCommands(fs, []string{"output", "secret"})
+	require.NoError(t, err)
+	var actual []string
+	for _, d := range diagnostics {
+		actual = append(actual, d.String())
+	}
+	assert.Equal(t, []string{
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl:1:12: calls output",
+		"/home/user/.local/share/chezmoi/.chezmoiignore:1:7: calls output",
+		"/home/user/.local/share/chezmoi/.chezmoitemplates/partial:1:20: calls secret",
+		"/home/user/.local/share/chezmoi/.chezmoitriggers:2:1: trigger: .tmux.conf tmux source-file ~/.tmux.conf",
+		"/home/user/.local/share/chezmoi/dot_bashrc.tmpl:3:12: calls output",
+		"/home/user/.local/share/chezmoi/dot_config/run_reload.sh:1:1: script",
+		"/home/user/.local/share/chezmoi/dot_config/symlink_dot_link.tmpl:1:4: calls secret",
+		"/home/user/.local/share/chezmoi/encrypted_dot_netrc.tmpl:1:1: encrypted template cannot be checked",
+		"/home/user/.local/share/chezmoi/run_once_install.sh.tmpl:1:1: script",
+		"/home/user/.local/share/chezmoi/run_once_install.sh.tmpl:3:5: calls secret",
+	}, actual)
+}
@@ -0,0 +1,162 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A ContentsChange is a change to the contents of a file or symlink in the
+// destination directory. The contents of a symlink are its target.
+type ContentsChange struct {
+	Name              string
+	Destination       []byte
+	DestinationExists bool
+	Target            []byte
+	TargetExists      bool
+}
+
+// A ContentsRecordingMutator wraps another Mutator and records the changes to
+// the contents of files and symlinks made by its mutating methods.
+type ContentsRecordingMutator struct {
+	m       Mutator
+	fs      vfs.FS
+	prefix  string
+	changes map[string]*ContentsChange
+}
+
+// NewContentsRecordingMutator returns a new ContentsRecordingMutator that
+// records changes to targets in destDir, reading their current contents from
+// fs.
+func NewContentsRecordingMutator(m Mutator, fs vfs.FS, destDir string) *ContentsRecordingMutator {
+	return &ContentsRecordingMutator{
+		m:       m,
+		fs:      fs,
+		prefix:  destDir + string(filepath.Separator),
+		changes: make(map[string]*ContentsChange),
+	}
+}
+
+// Changes returns all changes sorted by name, excluding any that leave the
+// contents unchanged.
+func (m *ContentsRecordingMutator) Changes() []*ContentsChange {
+	changes := make([]*ContentsChange, 0, len(m.changes))
+	for _, change := range m.changes {
+		if change.DestinationExists == change.TargetExists && string(change.Destination) == string(change.Target) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *ContentsRecordingMutator) Chmod(name string, mode os.FileMode) error {
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *ContentsRecordingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *ContentsRecordingMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *ContentsRecordingMutator) RemoveAll(name string) error {
+	if err := vfs.Walk(m.fs, name, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		}
+		if change := m.record(path); change != nil {
+			change.Target = nil
+			change.TargetExists = false
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *ContentsRecordingMutator) Rename(oldpath, newpath string) error {
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *ContentsRecordingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *ContentsRecordingMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *ContentsRecordingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if change := m.record(name); change != nil {
+		change.Target = data
+		change.TargetExists = true
+	}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *ContentsRecordingMutator) WriteSymlink(oldname, newname string) error {
+	if change := m.record(newname); change != nil {
+		change.Target = []byte(oldname)
+		change.TargetExists = true
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// record returns the change to name, reading its current contents the first
+// time that name is changed, or nil if name is not in the destination
+// directory.
+func (m *ContentsRecordingMutator) record(name string) *ContentsChange {
+	if !strings.HasPrefix(name, m.prefix) {
+		return nil
+	}
+	relName := strings.TrimPrefix(name, m.prefix)
+	if change, ok := m.changes[relName]; ok {
+		return change
+	}
+	change := &ContentsChange{
+		Name: relName,
+	}
+	if info, err := m.fs.Lstat(name); err == nil {
+		switch {
+		case info.Mode().IsRegular():
+			if data, err := m.fs.ReadFile(name); err == nil {
+				change.Destination = data
+				change.DestinationExists = true
+			}
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			if linkname, err := m.fs.Readlink(name); err == nil {
+				change.Destination = []byte(linkname)
+				change.DestinationExists = true
+			}
+		}
+	}
+	change.Target = change.Destination
+	change.TargetExists = change.DestinationExists
+	m.changes[relName] = change
+	return change
+}
@@ -0,0 +1,63 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &ContentsRecordingMutator{}
+
+func TestContentsRecordingMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc":    "# contents of .bashrc\n",
+			".inputrc":   "# contents of .inputrc\n",
+			".link":      &vfst.Symlink{Target: ".bashrc"},
+			".unchanged": "# contents of .unchanged\n",
+			".dir": map[string]interface{}{
+				"file": "# contents of .dir/file\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	m := NewContentsRecordingMutator(NullMutator{}, fs, "/home/user")
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# edited\n"), 0o644, []byte("# contents of .bashrc\n")))
+	require.NoError(t, m.RemoveAll("/home/user/.dir"))
+	require.NoError(t, m.RemoveAll("/home/user/.link"))
+	require.NoError(t, m.WriteSymlink(".inputrc", "/home/user/.link"))
+	require.NoError(t, m.WriteFile("/home/user/.profile", []byte("# contents of .profile\n"), 0o644, nil))
+	require.NoError(t, m.WriteFile("/home/user/.unchanged", []byte("# contents of .unchanged\n"), 0o644, nil))
+	require.NoError(t, m.WriteFile("/etc/outside", nil, 0o644, nil))
+
+	assert.Equal(t, []*ContentsChange{
+		{
+			Name:              ".bashrc",
+			Destination:       []byte("# contents of .bashrc\n"),
+			DestinationExists: true,
+			Target:            []byte("# edited\n"),
+			TargetExists:      true,
+		},
+		{
+			Name:              ".dir/file",
+			Destination:       []byte("# contents of .dir/file\n"),
+			DestinationExists: true,
+		},
+		{
+			Name:              ".link",
+			Destination:       []byte(".bashrc"),
+			DestinationExists: true,
+			Target:            []byte(".inputrc"),
+			TargetExists:      true,
+		},
+		{
+			Name:         ".profile",
+			Target:       []byte("# contents of .profile\n"),
+			TargetExists: true,
+		},
+	}, m.Changes())
+}
@@ -0,0 +1,42 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/pkg/diff"
+)
+
+// WriteUnifiedDiff writes a unified diff between aData and bData to w, labeled
+// with aName and bName.
+func WriteUnifiedDiff(w io.Writer, aName, bName string, aData, bData []byte, colored bool) error {
+	aLines, err := splitLines(aData)
+	if err != nil {
+		return err
+	}
+	bLines, err := splitLines(bData)
+	if err != nil {
+		return err
+	}
+	ab := diff.Strings(aLines, bLines)
+	e := diff.Myers(context.Background(), ab).WithContextSize(3)
+	opts := []diff.WriteOpt{
+		diff.Names(aName, bName),
+	}
+	if colored {
+		opts = append(opts, diff.TerminalColor())
+	}
+	_, err = e.WriteUnified(w, ab, opts...)
+	return err
+}
+
+func splitLines(data []byte) ([]string, error) {
+	var lines []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return lines, s.Err()
+}
@@ -0,0 +1,151 @@
+package chezmoi
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// An EntryTypeSet is a set of entry types.
+type EntryTypeSet int
+
+// Entry types.
+const (
+	EntryTypeDirs EntryTypeSet = 1 << iota
+	EntryTypeFiles
+	EntryTypeSymlinks
+	EntryTypeScripts
+	EntryTypeEncrypted
+	EntryTypeTemplates
+	EntryTypeRemoves
+
+	EntryTypesAll  EntryTypeSet = EntryTypeDirs | EntryTypeFiles | EntryTypeSymlinks | EntryTypeScripts | EntryTypeEncrypted | EntryTypeTemplates | EntryTypeRemoves
+	EntryTypesNone EntryTypeSet = 0
+)
+
+var entryTypeNames = map[string]EntryTypeSet{
+	"all":       EntryTypesAll,
+	"d":         EntryTypeDirs,
+	"dirs":      EntryTypeDirs,
+	"encrypted": EntryTypeEncrypted,
+	"f":         EntryTypeFiles,
+	"files":     EntryTypeFiles,
+	"none":      EntryTypesNone,
+	"removes":   EntryTypeRemoves,
+	"s":         EntryTypeSymlinks,
+	"scripts":   EntryTypeScripts,
+	"symlinks":  EntryTypeSymlinks,
+	"templates": EntryTypeTemplates,
+}
+
+// An EntryFilter selects entries by their types and target names. Targets that
+// are not entries in the target state, for example those removed by
+// .chezmoiremove or by exact directories, have type EntryTypeRemoves.
+type EntryFilter struct {
+	Include EntryTypeSet
+	Exclude EntryTypeSet
+	Globs   []string
+}
+
+// ParseEntryTypeSet parses the entry types in names.
+func ParseEntryTypeSet(names []string) (EntryTypeSet, error) {
+	entryTypeSet := EntryTypesNone
+	for _, name := range names {
+		for _, element := range strings.Split(name, ",") {
+			entryType, ok := entryTypeNames[strings.ToLower(strings.TrimSpace(element))]
+			if !ok {
+				return EntryTypesNone, fmt.Errorf("%s: unknown entry type", element)
+			}
+			entryTypeSet |= entryType
+		}
+	}
+	return entryTypeSet, nil
+}
+
+// NewEntryFilter returns a new EntryFilter, or nil if the EntryFilter would
+// include every entry.
+func NewEntryFilter(include, exclude EntryTypeSet, globs []string) (*EntryFilter, error) {
+	for _, glob := range globs {
+		if _, err := doublestar.PathMatch(glob, ""); err != nil {
+			return nil, fmt.Errorf("%s: %w", glob, err)
+		}
+	}
+	if include == EntryTypesAll && exclude == EntryTypesNone && len(globs) == 0 {
+		return nil, nil
+	}
+	return &EntryFilter{
+		Include: include,
+		Exclude: exclude,
+		Globs:   globs,
+	}, nil
+}
+
+// IncludeEntry returns true if f includes entry. A nil *EntryFilter includes
+// all entries.
+func (f *EntryFilter) IncludeEntry(entry Entry) bool {
+	if f == nil {
+		return true
+	}
+	return f.includeEntryTypes(entryTypes(entry)) && f.MatchGlobs(entry.TargetName())
+}
+
+// IncludeRemove returns true if f includes the removal of targetName.
+func (f *EntryFilter) IncludeRemove(targetName string) bool {
+	if f == nil {
+		return true
+	}
+	return f.includeEntryTypes(EntryTypeRemoves) && f.MatchGlobs(targetName)
+}
+
+// MatchGlobs returns true if f has no globs or if targetName or any of its
+// parent directories match any of f's globs.
+func (f *EntryFilter) MatchGlobs(targetName string) bool {
+	if f == nil || len(f.Globs) == 0 {
+		return true
+	}
+	for name := filepath.ToSlash(targetName); name != "."; name = filepath.ToSlash(filepath.Dir(name)) {
+		for _, glob := range f.Globs {
+			if ok, _ := doublestar.PathMatch(glob, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *EntryFilter) includeEntryTypes(entryTypes EntryTypeSet) bool {
+	return entryTypes&f.Include != 0 && entryTypes&f.Exclude == 0
+}
+
+// entryTypes returns the types of entry.
+func entryTypes(entry Entry) EntryTypeSet {
+	switch entry := entry.(type) {
+	case *Dir:
+		return EntryTypeDirs
+	case *File:
+		entryTypes := EntryTypeFiles
+		if entry.Encrypted {
+			entryTypes |= EntryTypeEncrypted
+		}
+		if entry.Template {
+			entryTypes |= EntryTypeTemplates
+		}
+		return entryTypes
+	case *Script:
+		entryTypes := EntryTypeScripts
+		if entry.Template {
+			entryTypes |= EntryTypeTemplates
+		}
+		return entryTypes
+	case *Symlink:
+		entryTypes := EntryTypeSymlinks
+		if entry.Template {
+			entryTypes |= EntryTypeTemplates
+		}
+		return entryTypes
+	default:
+		return EntryTypesNone
+	}
+}
@@ -0,0 +1,111 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntryTypeSet(t *testing.T) {
+	for _, tc := range []struct {
+		names       []string
+		expected    EntryTypeSet
+		expectedErr bool
+	}{
+		{
+			names:    nil,
+			expected: EntryTypesNone,
+		},
+		{
+			names:    []string{"all"},
+			expected: EntryTypesAll,
+		},
+		{
+			names:    []string{"d,f", "symlinks"},
+			expected: EntryTypeDirs | EntryTypeFiles | EntryTypeSymlinks,
+		},
+		{
+			names:    []string{"Scripts, templates"},
+			expected: EntryTypeScripts | EntryTypeTemplates,
+		},
+		{
+			names:       []string{"unknown"},
+			expectedErr: true,
+		},
+	} {
+		actual, err := ParseEntryTypeSet(tc.names)
+		if tc.expectedErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, actual)
+	}
+}
+
+func TestEntryFilter(t *testing.T) {
+	dir := &Dir{targetName: ".config"}
+	file := &File{targetName: ".config/file"}
+	encryptedFile := &File{targetName: ".encrypted", Encrypted: true}
+	templateFile := &File{targetName: ".template", Template: true}
+	script := &Script{targetName: "script.sh"}
+	symlink := &Symlink{targetName: ".symlink"}
+	allEntries := []Entry{dir, file, encryptedFile, templateFile, script, symlink}
+
+	for _, tc := range []struct {
+		name          string
+		include       EntryTypeSet
+		exclude       EntryTypeSet
+		globs         []string
+		expected      []Entry
+		expectRemoves bool
+	}{
+		{
+			name:          "exclude_scripts",
+			include:       EntryTypesAll,
+			exclude:       EntryTypeScripts,
+			expected:      []Entry{dir, file, encryptedFile, templateFile, symlink},
+			expectRemoves: true,
+		},
+		{
+			name:     "include_templates",
+			include:  EntryTypeTemplates,
+			expected: []Entry{templateFile},
+		},
+		{
+			name:     "exclude_encrypted",
+			include:  EntryTypeFiles,
+			exclude:  EntryTypeEncrypted,
+			expected: []Entry{file, templateFile},
+		},
+		{
+			name:          "glob",
+			include:       EntryTypesAll,
+			globs:         []string{".config"},
+			expected:      []Entry{dir, file},
+			expectRemoves: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewEntryFilter(tc.include, tc.exclude, tc.globs)
+			require.NoError(t, err)
+			var actual []Entry
+			for _, entry := range allEntries {
+				if f.IncludeEntry(entry) {
+					actual = append(actual, entry)
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectRemoves, f.IncludeRemove(".config/extra"))
+		})
+	}
+}
+
+func TestNilEntryFilter(t *testing.T) {
+	f, err := NewEntryFilter(EntryTypesAll, EntryTypesNone, nil)
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.IncludeEntry(&Script{targetName: "script.sh"}))
+	assert.True(t, f.IncludeRemove("file"))
+}
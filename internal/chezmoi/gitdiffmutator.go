@@ -1,8 +1,11 @@
 package chezmoi
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,53 +13,51 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/sergi/go-diff/diffmatchpatch"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/git"
 )
 
 // A GitDiffMutator wraps a Mutator and logs all of the actions it would execute
-// as a git diff.
+// as a git diff that can be applied with git apply or chezmoi apply-patch.
+// Directories are not included as git does not track them.
 type GitDiffMutator struct {
 	m              Mutator
+	fs             vfs.FS
 	prefix         string
 	unifiedEncoder *diff.UnifiedEncoder
+	removed        map[string]struct{}
 }
 
-// NewGitDiffMutator returns a new GitDiffMutator.
-func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, prefix string) *GitDiffMutator {
+// NewGitDiffMutator returns a new GitDiffMutator. fs is used to read the
+// current contents of files.
+func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, fs vfs.FS, prefix string) *GitDiffMutator {
 	return &GitDiffMutator{
 		m:              m,
+		fs:             fs,
 		prefix:         prefix,
 		unifiedEncoder: unifiedEncoder,
+		removed:        make(map[string]struct{}),
 	}
 }
 
 // Chmod implements Mutator.Chmod.
 func (m *GitDiffMutator) Chmod(name string, mode os.FileMode) error {
-	fromFileMode, info, err := m.getFileMode(name)
-	if err != nil {
+	from, data, err := m.getFile(name)
+	if err != nil || from == nil || from.fileMode == filemode.Symlink {
 		return err
 	}
-	// Assume that we're only changing permissions.
-	toFileMode, err := filemode.NewFromOSFileMode(info.Mode()&^os.ModePerm | mode)
+	toFileMode, err := filemode.NewFromOSFileMode(mode)
 	if err != nil {
 		return err
 	}
-	path := m.trimPrefix(name)
-	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				from: &gitDiffFile{
-					fileMode: fromFileMode,
-					path:     path,
-					hash:     plumbing.ZeroHash,
-				},
-				to: &gitDiffFile{
-					fileMode: toFileMode,
-					path:     path,
-					hash:     plumbing.ZeroHash,
-				},
-			},
-		},
-	})
+	// git only records whether files are executable.
+	if toFileMode == from.fileMode {
+		return nil
+	}
+	to := *from
+	to.fileMode = toFileMode
+	return m.encodeFilePatch(from, &to, data, data)
 }
 
 // IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
@@ -66,40 +67,35 @@ func (m *GitDiffMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 
 // Mkdir implements Mutator.Mkdir.
 func (m *GitDiffMutator) Mkdir(name string, perm os.FileMode) error {
-	toFileMode, err := filemode.NewFromOSFileMode(os.ModeDir | perm)
-	if err != nil {
-		return err
-	}
-	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				to: &gitDiffFile{
-					fileMode: toFileMode,
-					path:     m.trimPrefix(name),
-					hash:     plumbing.ZeroHash,
-				},
-			},
-		},
-	})
+	// git does not track directories, and git apply creates any parent
+	// directories that it needs.
+	return nil
 }
 
 // RemoveAll implements Mutator.RemoveAll.
 func (m *GitDiffMutator) RemoveAll(name string) error {
-	fromFileMode, _, err := m.getFileMode(name)
-	if err != nil {
+	if m.isRemoved(name) {
+		return nil
+	}
+	if err := vfs.Walk(m.fs, name, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir():
+			return nil
+		}
+		from, data, err := m.getFile(path)
+		if err != nil || from == nil {
+			return err
+		}
+		return m.encodeFilePatch(from, nil, data, nil)
+	}); err != nil {
 		return err
 	}
-	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				from: &gitDiffFile{
-					fileMode: fromFileMode,
-					path:     m.trimPrefix(name),
-					hash:     plumbing.ZeroHash,
-				},
-			},
-		},
-	})
+	m.removed[name] = struct{}{}
+	return nil
 }
 
 // RunCmd implements Mutator.RunCmd.
@@ -115,94 +111,177 @@ func (m *GitDiffMutator) Stat(name string) (os.FileInfo, error) {
 
 // Rename implements Mutator.Rename.
 func (m *GitDiffMutator) Rename(oldpath, newpath string) error {
-	fileMode, _, err := m.getFileMode(oldpath)
-	if err != nil {
+	from, data, err := m.getFile(oldpath)
+	if err != nil || from == nil {
 		return err
 	}
-	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				from: &gitDiffFile{
-					fileMode: fileMode,
-					path:     m.trimPrefix(oldpath),
-					hash:     plumbing.ZeroHash,
-				},
-				to: &gitDiffFile{
-					fileMode: fileMode,
-					path:     m.trimPrefix(newpath),
-					hash:     plumbing.ZeroHash,
-				},
-			},
-		},
-	})
+	to := *from
+	to.path = m.trimPrefix(newpath)
+	if err := m.encodeFilePatch(from, &to, data, data); err != nil {
+		return err
+	}
+	m.removed[oldpath] = struct{}{}
+	return nil
 }
 
 // WriteFile implements Mutator.WriteFile.
 func (m *GitDiffMutator) WriteFile(filename string, data []byte, perm os.FileMode, currData []byte) error {
-	fileMode, _, err := m.getFileMode(filename)
+	from, _, err := m.getFile(filename)
 	if err != nil {
 		return err
 	}
-	path := m.trimPrefix(filename)
-	isBinary := isBinary(currData) || isBinary(data)
-	var chunks []diff.Chunk
-	if !isBinary {
-		chunks = diffChunks(string(currData), string(data))
+	toFileMode, err := filemode.NewFromOSFileMode(perm)
+	if err != nil {
+		return err
 	}
-	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				isBinary: isBinary,
-				from: &gitDiffFile{
-					fileMode: fileMode,
-					path:     path,
-					hash:     plumbing.ComputeHash(plumbing.BlobObject, currData),
-				},
-				to: &gitDiffFile{
-					fileMode: fileMode,
-					path:     path,
-					hash:     plumbing.ComputeHash(plumbing.BlobObject, data),
-				},
-				chunks: chunks,
-			},
-		},
-	})
+	return m.encodeFilePatch(from, &gitDiffFile{
+		fileMode: toFileMode,
+		path:     m.trimPrefix(filename),
+		hash:     plumbing.ComputeHash(plumbing.BlobObject, data),
+	}, currData, data)
 }
 
 // WriteSymlink implements Mutator.WriteSymlink.
 func (m *GitDiffMutator) WriteSymlink(oldname, newname string) error {
+	from, fromData, err := m.getFile(newname)
+	if err != nil {
+		return err
+	}
+	// A change of type is a deletion followed by a creation.
+	if from != nil && from.fileMode != filemode.Symlink {
+		if err := m.encodeFilePatch(from, nil, fromData, nil); err != nil {
+			return err
+		}
+		from, fromData = nil, nil
+	}
+	return m.encodeFilePatch(from, &gitDiffFile{
+		fileMode: filemode.Symlink,
+		path:     m.trimPrefix(newname),
+		hash:     plumbing.ComputeHash(plumbing.BlobObject, []byte(oldname)),
+	}, fromData, []byte(oldname))
+}
+
+// encodeFilePatch writes the patch from from to to, either of which may be nil
+// if the file does not exist, with contents fromData and toData.
+func (m *GitDiffMutator) encodeFilePatch(from, to *gitDiffFile, fromData, toData []byte) error {
+	if isBinary(fromData) || isBinary(toData) {
+		return m.writeBinaryFilePatch(from, to, fromData, toData)
+	}
+	filePatch := &gitDiffFilePatch{
+		chunks: diffChunks(string(fromData), string(toData)),
+	}
+	// Assign from and to only if they are non-nil so that the diff.File
+	// interface values are nil for missing files.
+	if from != nil {
+		filePatch.from = from
+	}
+	if to != nil {
+		filePatch.to = to
+	}
 	return m.unifiedEncoder.Encode(&gitDiffPatch{
-		filePatches: []diff.FilePatch{
-			&gitDiffFilePatch{
-				to: &gitDiffFile{
-					fileMode: filemode.Symlink,
-					path:     m.trimPrefix(newname),
-					hash:     plumbing.ComputeHash(plumbing.BlobObject, []byte(oldname)),
-				},
-				chunks: []diff.Chunk{
-					&gitDiffChunk{
-						content:   oldname,
-						operation: diff.Add,
-					},
-				},
-			},
-		},
+		filePatches: []diff.FilePatch{filePatch},
 	})
 }
 
-func (m *GitDiffMutator) getFileMode(name string) (filemode.FileMode, os.FileInfo, error) {
-	info, err := m.m.Stat(name)
-	if os.IsNotExist(err) {
-		return filemode.Empty, nil, nil
-	} else if err != nil {
-		return filemode.Empty, nil, err
+// getFile returns the file at name and its contents, or nil if name does not
+// exist, has been removed, or is a directory. The contents of a symlink are its
+// target.
+func (m *GitDiffMutator) getFile(name string) (*gitDiffFile, []byte, error) {
+	if m.isRemoved(name) {
+		return nil, nil, nil
+	}
+	info, err := m.fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil, nil
+	case err != nil:
+		return nil, nil, err
+	case info.IsDir():
+		return nil, nil, nil
+	}
+	var data []byte
+	if info.Mode()&os.ModeType == os.ModeSymlink {
+		linkname, err := m.fs.Readlink(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = []byte(linkname)
+	} else if data, err = m.fs.ReadFile(name); err != nil {
+		return nil, nil, err
 	}
 	fileMode, err := filemode.NewFromOSFileMode(info.Mode())
-	return fileMode, info, err
+	if err != nil {
+		return nil, nil, err
+	}
+	return &gitDiffFile{
+		fileMode: fileMode,
+		path:     m.trimPrefix(name),
+		hash:     plumbing.ComputeHash(plumbing.BlobObject, data),
+	}, data, nil
+}
+
+// isRemoved returns true if name or any of its parent directories have been
+// removed.
+func (m *GitDiffMutator) isRemoved(name string) bool {
+	for {
+		if _, ok := m.removed[name]; ok {
+			return true
+		}
+		parent := filepath.Dir(name)
+		if parent == name {
+			return false
+		}
+		name = parent
+	}
 }
 
 func (m *GitDiffMutator) trimPrefix(path string) string {
-	return strings.TrimPrefix(path, m.prefix)
+	return filepath.ToSlash(strings.TrimPrefix(path, m.prefix))
+}
+
+// writeBinaryFilePatch writes the patch from from to to as a git binary patch,
+// which git apply can apply, unlike the "Binary files differ" message written
+// by diff.UnifiedEncoder.
+func (m *GitDiffMutator) writeBinaryFilePatch(from, to *gitDiffFile, fromData, toData []byte) error {
+	b := &bytes.Buffer{}
+	switch {
+	case from == nil:
+		fmt.Fprintf(b, "diff --git a/%s b/%s\n", to.path, to.path)
+		fmt.Fprintf(b, "new file mode %o\n", to.fileMode)
+		fmt.Fprintf(b, "index %s..%s\n", plumbing.ZeroHash, to.hash)
+	case to == nil:
+		fmt.Fprintf(b, "diff --git a/%s b/%s\n", from.path, from.path)
+		fmt.Fprintf(b, "deleted file mode %o\n", from.fileMode)
+		fmt.Fprintf(b, "index %s..%s\n", from.hash, plumbing.ZeroHash)
+	default:
+		fmt.Fprintf(b, "diff --git a/%s b/%s\n", from.path, to.path)
+		if from.fileMode != to.fileMode {
+			fmt.Fprintf(b, "old mode %o\n", from.fileMode)
+			fmt.Fprintf(b, "new mode %o\n", to.fileMode)
+		}
+		if from.path != to.path {
+			fmt.Fprintf(b, "rename from %s\n", from.path)
+			fmt.Fprintf(b, "rename to %s\n", to.path)
+		}
+		if from.hash == to.hash {
+			_, err := m.unifiedEncoder.Write(b.Bytes())
+			return err
+		}
+		if from.fileMode != to.fileMode {
+			fmt.Fprintf(b, "index %s..%s\n", from.hash, to.hash)
+		} else {
+			fmt.Fprintf(b, "index %s..%s %o\n", from.hash, to.hash, from.fileMode)
+		}
+	}
+	b.WriteString("GIT binary patch\n")
+	if err := git.WriteBinaryLiteral(b, toData); err != nil {
+		return err
+	}
+	if err := git.WriteBinaryLiteral(b, fromData); err != nil {
+		return err
+	}
+	_, err := m.unifiedEncoder.Write(b.Bytes())
+	return err
 }
 
 var gitDiffOperation = map[diffmatchpatch.Operation]diff.Operation{
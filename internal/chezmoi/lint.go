@@ -0,0 +1,368 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// attributePrefixes are all the prefixes that can appear in source names.
+var attributePrefixes = []string{
+	dotPrefix,
+	emptyPrefix,
+	encryptedPrefix,
+	exactPrefix,
+	executablePrefix,
+	oncePrefix,
+	privatePrefix,
+	runPrefix,
+	symlinkPrefix,
+}
+
+// A Diagnostic is a problem found in the source state.
+type Diagnostic struct {
+	Path    string
+	Line    int
+	Col     int
+	Message string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.Path, d.Line, d.Col, d.Message)
+}
+
+// A lintTemplate is a parsed template to be linted.
+type lintTemplate struct {
+	path string
+	td   *templateDirective
+	text string
+	tmpl *template.Template
+}
+
+// A templateLinter checks templates against the template data.
+type templateLinter struct {
+	dataProfiles []map[string]interface{}
+	diagnostics  []*Diagnostic
+	path         string
+	td           *templateDirective
+	templates    map[string]bool
+	text         string
+}
+
+// Lint parses all templates in the source state in fs and checks all source
+// names, returning a Diagnostic for each problem found. Data keys referenced
+// by templates must exist in at least one of dataProfiles. Encrypted files are
+// not checked.
+func (ts *TargetState) Lint(fs vfs.FS, dataProfiles []map[string]interface{}) ([]*Diagnostic, error) {
+	var diagnostics []*Diagnostic
+	var partialPaths []string
+	var templatePaths []string
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(ts.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		name := info.Name()
+		if strings.HasPrefix(name, ".") {
+			switch {
+			case name == ignoreName || name == removeName || name == triggersName:
+				templatePaths = append(templatePaths, path)
+			case name == templatesDirName:
+				if err := vfs.Walk(fs, path, func(path string, info os.FileInfo, err error) error {
+					if err == nil && info.Mode().IsRegular() {
+						partialPaths = append(partialPaths, path)
+					}
+					return err
+				}); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			case info.IsDir():
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, message := range checkSourceName(name, info.IsDir()) {
+			diagnostics = append(diagnostics, &Diagnostic{
+				Path:    path,
+				Line:    1,
+				Col:     1,
+				Message: message,
+			})
+		}
+		if !info.Mode().IsRegular() || !strings.HasSuffix(name, TemplateSuffix) {
+			return nil
+		}
+		if !strings.HasPrefix(name, runPrefix) && ParseFileAttributes(name).Encrypted {
+			return nil
+		}
+		templatePaths = append(templatePaths, path)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Parse all partials first so that references to them can be checked.
+	tl := &templateLinter{
+		dataProfiles: dataProfiles,
+		templates:    make(map[string]bool),
+	}
+	var partials []*lintTemplate
+	for _, path := range partialPaths {
+		dir := path
+		for filepath.Base(dir) != templatesDirName {
+			dir = filepath.Dir(dir)
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(path), filepath.ToSlash(dir)+"/")
+		lt, err := ts.parseLintTemplate(fs, tl, path, name)
+		if err != nil {
+			return nil, err
+		}
+		if lt != nil {
+			partials = append(partials, lt)
+			tl.templates[name] = true
+		}
+	}
+	var templates []*lintTemplate
+	for _, path := range templatePaths {
+		lt, err := ts.parseLintTemplate(fs, tl, path, path)
+		if err != nil {
+			return nil, err
+		}
+		if lt != nil {
+			templates = append(templates, lt)
+		}
+	}
+
+	// The value of dot in partials is unknown, so only check references to
+	// the template data in templates in the source state.
+	for _, lt := range partials {
+		tl.lint(lt, false)
+	}
+	for _, lt := range templates {
+		tl.lint(lt, true)
+	}
+
+	diagnostics = append(diagnostics, tl.diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Path < diagnostics[j].Path
+	})
+	return diagnostics, nil
+}
+
+// parseLintTemplate parses the template at path with name, recording any
+// parse error as a diagnostic in tl.
+func (ts *TargetState) parseLintTemplate(fs vfs.FS, tl *templateLinter, path, name string) (*lintTemplate, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	td, data, err := parseTemplateDirective(data)
+	if err != nil {
+		tl.diagnostics = append(tl.diagnostics, &Diagnostic{
+			Path:    path,
+			Line:    1,
+			Col:     1,
+			Message: err.Error(),
+		})
+		return nil, nil
+	}
+	tmpl, err := td.apply(ts.newTemplate(name)).Parse(string(data))
+	if err != nil {
+		line, message := parseTemplateError(name, err)
+		tl.diagnostics = append(tl.diagnostics, &Diagnostic{
+			Path:    path,
+			Line:    td.fileLine(line),
+			Col:     1,
+			Message: message,
+		})
+		return nil, nil
+	}
+	return &lintTemplate{
+		path: path,
+		td:   td,
+		text: string(data),
+		tmpl: tmpl,
+	}, nil
+}
+
+// lint checks all the parse trees in lt. If root is true then dot in lt's
+// main tree is the root template data.
+func (tl *templateLinter) lint(lt *lintTemplate, root bool) {
+	tl.path = lt.path
+	tl.td = lt.td
+	tl.text = lt.text
+	defined := make(map[string]bool)
+	for _, t := range lt.tmpl.Templates() {
+		defined[t.Name()] = true
+	}
+	for _, t := range lt.tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		main := root && t.Name() == lt.tmpl.Name()
+		tl.walk(t.Tree.Root, main, main, defined)
+	}
+}
+
+// walk walks node, checking field and template references. dot is true if
+// the value of dot is the root template data and dollar is true if $ is the
+// root template data.
+func (tl *templateLinter) walk(node parse.Node, dot, dollar bool, defined map[string]bool) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		tl.walk(node.Pipe, dot, dollar, defined)
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			tl.walk(arg, dot, dollar, defined)
+		}
+	case *parse.FieldNode:
+		if dot {
+			tl.checkKeys(node, node.Ident)
+		}
+	case *parse.IfNode:
+		tl.walk(node.Pipe, dot, dollar, defined)
+		tl.walk(node.List, dot, dollar, defined)
+		tl.walk(node.ElseList, dot, dollar, defined)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			tl.walk(n, dot, dollar, defined)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			tl.walk(cmd, dot, dollar, defined)
+		}
+	case *parse.RangeNode:
+		tl.walk(node.Pipe, dot, dollar, defined)
+		tl.walk(node.List, false, dollar, defined)
+		tl.walk(node.ElseList, dot, dollar, defined)
+	case *parse.TemplateNode:
+		if !defined[node.Name] && !tl.templates[node.Name] {
+			tl.addDiagnostic(node, fmt.Sprintf("template %q not defined", node.Name))
+		}
+		tl.walk(node.Pipe, dot, dollar, defined)
+	case *parse.VariableNode:
+		if dollar && len(node.Ident) > 1 && node.Ident[0] == "$" {
+			tl.checkKeys(node, node.Ident[1:])
+		}
+	case *parse.WithNode:
+		tl.walk(node.Pipe, dot, dollar, defined)
+		tl.walk(node.List, false, dollar, defined)
+		tl.walk(node.ElseList, dot, dollar, defined)
+	}
+}
+
+// checkKeys records a diagnostic if keys is not present in any data profile.
+func (tl *templateLinter) checkKeys(node parse.Node, keys []string) {
+	for _, dataProfile := range tl.dataProfiles {
+		if hasKeys(dataProfile, keys) {
+			return
+		}
+	}
+	tl.addDiagnostic(node, fmt.Sprintf(".%s is not defined in the template data", strings.Join(keys, ".")))
+}
+
+func (tl *templateLinter) addDiagnostic(node parse.Node, message string) {
+	line, col := lineCol(tl.text, int(node.Position()))
+	tl.diagnostics = append(tl.diagnostics, &Diagnostic{
+		Path:    tl.path,
+		Line:    tl.td.fileLine(line),
+		Col:     col,
+		Message: message,
+	})
+}
+
+// checkSourceName returns a message for each problem with sourceName.
+func checkSourceName(sourceName string, isDir bool) []string {
+	var messages []string
+	var name string
+	switch {
+	case isDir:
+		name = ParseDirAttributes(sourceName).Name
+		if strings.HasSuffix(name, TemplateSuffix) {
+			messages = append(messages, fmt.Sprintf("directories cannot be templates, %s suffix is treated as part of the target name %q", TemplateSuffix, name))
+		}
+	case strings.HasPrefix(sourceName, runPrefix):
+		name = ParseScriptAttributes(sourceName).Name
+	default:
+		name = ParseFileAttributes(sourceName).Name
+	}
+	rest := strings.TrimPrefix(name, ".")
+	if rest == "" {
+		return append(messages, fmt.Sprintf("empty target name %q", name))
+	}
+	for _, prefix := range attributePrefixes {
+		if strings.HasPrefix(rest, prefix) {
+			messages = append(messages, fmt.Sprintf("%s prefix is out of order or not valid here and is treated as part of the target name %q", prefix, name))
+			break
+		}
+	}
+	return messages
+}
+
+// hasKeys returns false if the nested value of keys in data is known not to
+// exist.
+func hasKeys(data interface{}, keys []string) bool {
+	for _, key := range keys {
+		switch m := data.(type) {
+		case map[string]interface{}:
+			value, ok := m[key]
+			if !ok {
+				return false
+			}
+			data = value
+		case map[string]string:
+			value, ok := m[key]
+			if !ok {
+				return false
+			}
+			data = value
+		default:
+			// Methods and fields of other types are not checked.
+			return true
+		}
+	}
+	return true
+}
+
+// lineCol returns the one-based line and column of offset in text.
+func lineCol(text string, offset int) (int, int) {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	before := text[:offset]
+	line := 1 + strings.Count(before, "\n")
+	col := 1 + offset - (strings.LastIndex(before, "\n") + 1)
+	return line, col
+}
+
+// parseTemplateError returns the line and message of err, a parse error from
+// the template name.
+func parseTemplateError(name string, err error) (int, string) {
+	s := strings.TrimPrefix(err.Error(), "template: "+name+":")
+	if i := strings.Index(s, ": "); i != -1 {
+		if line, err := strconv.Atoi(s[:i]); err == nil {
+			return line, s[i+2:]
+		}
+	}
+	return 1, err.Error()
+}
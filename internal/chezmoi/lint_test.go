@@ -0,0 +1,141 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestCheckSourceName(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName string
+		isDir      bool
+		expected   []string
+	}{
+		{
+			sourceName: "dot_bashrc",
+		},
+		{
+			sourceName: "encrypted_private_empty_executable_dot_foo.tmpl",
+		},
+		{
+			sourceName: "exact_private_dot_dir",
+			isDir:      true,
+		},
+		{
+			sourceName: "run_once_install.sh.tmpl",
+		},
+		{
+			sourceName: "executable_private_dot_foo",
+			expected: []string{
+				`private_ prefix is out of order or not valid here and is treated as part of the target name "private_dot_foo"`,
+			},
+		},
+		{
+			sourceName: "dot_private_foo",
+			expected: []string{
+				`private_ prefix is out of order or not valid here and is treated as part of the target name ".private_foo"`,
+			},
+		},
+		{
+			sourceName: "exact_file",
+			expected: []string{
+				`exact_ prefix is out of order or not valid here and is treated as part of the target name "exact_file"`,
+			},
+		},
+		{
+			sourceName: "once_run_script",
+			expected: []string{
+				`once_ prefix is out of order or not valid here and is treated as part of the target name "once_run_script"`,
+			},
+		},
+		{
+			sourceName: "executable_dir",
+			isDir:      true,
+			expected: []string{
+				`executable_ prefix is out of order or not valid here and is treated as part of the target name "executable_dir"`,
+			},
+		},
+		{
+			sourceName: "dir.tmpl",
+			isDir:      true,
+			expected: []string{
+				`directories cannot be templates, .tmpl suffix is treated as part of the target name "dir.tmpl"`,
+			},
+		},
+		{
+			sourceName: "private_",
+			expected: []string{
+				`empty target name ""`,
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, checkSourceName(tc.sourceName, tc.isDir))
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiignore": "{{ if .work }}README.md{{ end }}\n",
+			".chezmoitemplates": map[string]interface{}{
+				"footer": "{{ .anything }}{{ upper \"footer\" }}\n",
+				"broken": "{{ if }}\n",
+				"cycle1": "{{ includeTemplate \"cycle2\" . }}",
+				"cycle2": "{{ template \"cycle1\" . }}",
+			},
+			".git/dot_private_ignored":    "",
+			"dot_bashrc.tmpl":             "# {{ .email }}\n{{ template \"footer\" . }}",
+			"dot_gitconfig.tmpl":          "[user]\n\temail = {{ .emial }}\n{{ range .list }}{{ .name }}{{ end }}{{ $.chezmoi.os }}\n",
+			"dot_hgrc.tmpl":               "{{ template \"missing\" }}\n",
+			"dot_helm.tmpl":               "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n{{ .Values.helm }}\n[[ .helm ]]\n",
+			"dot_vimrc.tmpl":              "\n  {{ unknownFunc }}\n",
+			"encrypted_dot_netrc.tmpl":    "{{ .notChecked }}",
+			"executable_private_dot_foo":  "",
+			"private_dot_ssh/config.tmpl": "{{ with .ssh }}{{ .host }}{{ else }}{{ .ssh2 }}{{ end }}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateFuncs(template.FuncMap{
+			"upper": strings.ToUpper,
+		}),
+	)
+	dataProfiles := []map[string]interface{}{
+		{
+			"chezmoi": map[string]interface{}{
+				"os": "linux",
+			},
+			"email": "user@home.org",
+			"list":  []interface{}{},
+			"ssh":   "host",
+		},
+		{
+			"work": true,
+		},
+	}
+	diagnostics, err := ts.Lint(fs, dataProfiles)
+	require.NoError(t, err)
+	var actual []string
+	for _, diagnostic := range diagnostics {
+		actual = append(actual, diagnostic.String())
+	}
+	assert.Equal(t, []string{
+		`/home/user/.local/share/chezmoi/.chezmoitemplates/broken:1:1: missing value for if`,
+		`/home/user/.local/share/chezmoi/dot_gitconfig.tmpl:2:13: .emial is not defined in the template data`,
+		`/home/user/.local/share/chezmoi/dot_helm.tmpl:3:4: .helm is not defined in the template data`,
+		`/home/user/.local/share/chezmoi/dot_hgrc.tmpl:1:13: template "missing" not defined`,
+		`/home/user/.local/share/chezmoi/dot_vimrc.tmpl:2:1: function "unknownFunc" not defined`,
+		`/home/user/.local/share/chezmoi/executable_private_dot_foo:1:1: private_ prefix is out of order or not valid here and is treated as part of the target name "private_dot_foo"`,
+		`/home/user/.local/share/chezmoi/private_dot_ssh/config.tmpl:1:40: .ssh2 is not defined in the template data`,
+	}, actual)
+}
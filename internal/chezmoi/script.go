@@ -6,19 +6,36 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	vfs "github.com/twpayne/go-vfs"
 )
 
+// scriptOutputGracePeriod is how long to wait for output from a script after
+// it exits.
+const scriptOutputGracePeriod = time.Second
+
+// scriptTimeoutRegexp matches a per-script timeout directive.
+var scriptTimeoutRegexp = regexp.MustCompile(`chezmoi:script:timeout=(\S+)`)
+
 // FIXME allow encrypted scripts
 // FIXME add pre- and post- attributes
 
+// An Interpreter interprets scripts.
+type Interpreter struct {
+	Command string   `json:"command" yaml:"command"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
 // A ScriptAttributes holds attributes parsed from a source script name.
 type ScriptAttributes struct {
 	Name     string
@@ -38,18 +55,20 @@ type Script struct {
 	targetName       string
 	Once             bool
 	Template         bool
+	Interpreter      *Interpreter
 	contents         []byte
 	contentsErr      error
 	evaluateContents func() ([]byte, error)
 }
 
 type scriptConcreteValue struct {
-	Type       string `json:"type" yaml:"type"`
-	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
-	TargetPath string `json:"targetPath" yaml:"targetPath"`
-	Once       bool   `json:"once" yaml:"once"`
-	Template   bool   `json:"template" yaml:"template"`
-	Contents   string `json:"contents" yaml:"contents"`
+	Type        string       `json:"type" yaml:"type"`
+	SourcePath  string       `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath  string       `json:"targetPath" yaml:"targetPath"`
+	Once        bool         `json:"once" yaml:"once"`
+	Template    bool         `json:"template" yaml:"template"`
+	Interpreter *Interpreter `json:"interpreter,omitempty" yaml:"interpreter,omitempty"`
+	Contents    string       `json:"contents" yaml:"contents"`
 }
 
 // ParseScriptAttributes parses a source script file name.
@@ -92,7 +111,7 @@ func (s *Script) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply runs s.
 func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.NoScripts || applyOptions.Ignore(s.targetName) {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -105,8 +124,7 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 
 	var key []byte
 	if s.Once {
-		contentsKeyArr := sha256.Sum256(contents)
-		key = []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+		key = s.stateKey(contents)
 		scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, key)
 		if err != nil {
 			return err
@@ -125,34 +143,10 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return nil
 	}
 
-	// Write the temporary script file. Put the randomness on the front of the
-	// filename to preserve any file extension for Windows scripts.
-	f, err := ioutil.TempFile("", "*."+filepath.Base(s.targetName))
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		_ = os.RemoveAll(f.Name())
-	}()
-	if err := os.Chmod(f.Name(), 0o700); err != nil {
-		return err
-	}
-	if _, err := f.Write(contents); err != nil {
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-
-	// Run the temporary script file.
-	//nolint:gosec
-	c := exec.Command(f.Name())
-	c.Dir = filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	if err := c.Run(); err != nil {
+	if err := s.run(s.targetName, filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName)), contents, applyOptions); err != nil {
+		if applyOptions.ScriptError != nil {
+			return applyOptions.ScriptError(s, err)
+		}
 		return err
 	}
 
@@ -170,7 +164,7 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		}
 	}
 
-	return err
+	return nil
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
@@ -183,12 +177,13 @@ func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask
 		return nil, err
 	}
 	return &scriptConcreteValue{
-		Type:       "script",
-		SourcePath: filepath.Join(sourceDir, s.SourceName()),
-		TargetPath: s.TargetName(),
-		Once:       s.Once,
-		Template:   s.Template,
-		Contents:   string(contents),
+		Type:        "script",
+		SourcePath:  filepath.Join(sourceDir, s.SourceName()),
+		TargetPath:  s.TargetName(),
+		Once:        s.Once,
+		Template:    s.Template,
+		Interpreter: s.Interpreter,
+		Contents:    string(contents),
 	}, nil
 }
 
@@ -210,6 +205,28 @@ func (s *Script) Evaluate(ignore func(string) bool) error {
 	return err
 }
 
+// NeedsRun returns true if Apply would run s with applyOptions.
+func (s *Script) NeedsRun(applyOptions *ApplyOptions) (bool, error) {
+	if applyOptions.NoScripts || applyOptions.Ignore(s.targetName) {
+		return false, nil
+	}
+	contents, err := s.Contents()
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(contents)) == 0 {
+		return false, nil
+	}
+	if !s.Once {
+		return true, nil
+	}
+	scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, s.stateKey(contents))
+	if err != nil {
+		return false, err
+	}
+	return scriptStateData == nil, nil
+}
+
 // SourceName implements Entry.SourceName.
 func (s *Script) SourceName() string {
 	return s.sourceName
@@ -220,6 +237,187 @@ func (s *Script) TargetName() string {
 	return s.targetName
 }
 
+// command returns the command to run the script at path, using s's
+// interpreter if it has one.
+func (s *Script) command(path string) *exec.Cmd {
+	if s.Interpreter == nil {
+		//nolint:gosec
+		return exec.Command(path)
+	}
+	args := append(append([]string{}, s.Interpreter.Args...), path)
+	//nolint:gosec
+	return exec.Command(s.Interpreter.Command, args...)
+}
+
+// run runs contents as s in dir, using name in logs and errors.
+func (s *Script) run(name, dir string, contents []byte, applyOptions *ApplyOptions) error {
+	// Write the temporary script file. Put the randomness on the front of the
+	// filename to preserve any file extension for Windows scripts.
+	f, err := ioutil.TempFile("", "*."+filepath.Base(s.targetName))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(f.Name())
+	}()
+	if err := os.Chmod(f.Name(), 0o700); err != nil {
+		return err
+	}
+	if _, err := f.Write(contents); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	timeout := applyOptions.ScriptTimeout
+	if m := scriptTimeoutRegexp.FindSubmatch(contents); m != nil {
+		timeout, err = time.ParseDuration(string(m[1]))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	// Run the temporary script file.
+	c := s.command(f.Name())
+	if applyOptions.ScriptEnv != nil {
+		c.Env = append(os.Environ(), applyOptions.ScriptEnv...)
+	}
+	c.Dir = dir
+	c.Stdin = os.Stdin
+	var log *scriptLogWriter
+	var logWriter io.Writer
+	if applyOptions.ScriptLog != nil {
+		log = &scriptLogWriter{
+			w:      applyOptions.ScriptLog,
+			header: fmt.Sprintf("==> %s: started at %s\n", name, time.Now().Format(time.RFC3339)),
+		}
+		logWriter = log
+	}
+	err = runScript(c, timeout, logWriter)
+	if log != nil && log.Started() {
+		status := "ok"
+		if err != nil {
+			status = err.Error()
+		}
+		fmt.Fprintf(log, "==> %s: %s\n", name, status)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// runScript runs cmd, killing it if it does not complete within timeout. If
+// timeout is zero then cmd is not killed. If log is not nil then cmd's stdout
+// and stderr are also written to log.
+func runScript(cmd *exec.Cmd, timeout time.Duration, log io.Writer) error {
+	var readers, writers []*os.File
+	closeWriters := func() {
+		for _, w := range writers {
+			_ = w.Close()
+		}
+		writers = nil
+	}
+	defer closeWriters()
+	var copyWG sync.WaitGroup
+	if log == nil {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		// Use pipes rather than io.Writers so that cmd.Wait does not wait for
+		// output from any background processes started by cmd.
+		for _, out := range []*os.File{os.Stdout, os.Stderr} {
+			r, w, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+			readers = append(readers, r)
+			writers = append(writers, w)
+			copyWG.Add(1)
+			go func(dst io.Writer, src io.Reader) {
+				defer copyWG.Done()
+				_, _ = io.Copy(dst, src)
+			}(io.MultiWriter(out, log), r)
+		}
+		cmd.Stdout = writers[0]
+		cmd.Stderr = writers[1]
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	waitErrCh := make(chan error, 1)
+	go func() {
+		waitErrCh <- cmd.Wait()
+	}()
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	var err error
+	select {
+	case err = <-waitErrCh:
+	case <-timeoutCh:
+		_ = cmd.Process.Kill()
+		<-waitErrCh
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	if log != nil {
+		// Close our copies of the write ends of the pipes and wait briefly
+		// for the remaining output, which might never end if cmd left
+		// background processes running.
+		closeWriters()
+		copyDoneCh := make(chan struct{})
+		go func() {
+			copyWG.Wait()
+			close(copyDoneCh)
+		}()
+		select {
+		case <-copyDoneCh:
+		case <-time.After(scriptOutputGracePeriod):
+		}
+		for _, r := range readers {
+			_ = r.Close()
+		}
+	}
+
+	return err
+}
+
+// A scriptLogWriter is an io.Writer that writes a script's output to a log,
+// preceded by a header that is only written when the script first produces
+// output. It can be written to concurrently.
+type scriptLogWriter struct {
+	sync.Mutex
+	w       io.Writer
+	header  string
+	started bool
+}
+
+// Started returns true if w has written its header.
+func (w *scriptLogWriter) Started() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.started
+}
+
+func (w *scriptLogWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if !w.started {
+		if _, err := io.WriteString(w.w, w.header); err != nil {
+			return 0, err
+		}
+		w.started = true
+	}
+	return w.w.Write(p)
+}
+
 // archive writes s to w.
 func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
 	if ignore(s.targetName) {
@@ -240,3 +438,10 @@ func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate
 	_, err = w.Write(contents)
 	return err
 }
+
+// stateKey returns the key of s's state in the script state bucket, given its
+// contents.
+func (s *Script) stateKey(contents []byte) []byte {
+	contentsKeyArr := sha256.Sum256(contents)
+	return []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+}
@@ -21,6 +21,10 @@ import (
 // DefaultTemplateOptions are the default template options.
 var DefaultTemplateOptions = []string{"missingkey=error"}
 
+// maxIncludeTemplateDepth is the maximum depth of nested calls to
+// includeTemplate.
+const maxIncludeTemplateDepth = 100
+
 const (
 	ignoreName       = ".chezmoiignore"
 	removeName       = ".chezmoiremove"
@@ -48,13 +52,18 @@ type ImportTAROptions struct {
 // A PopulateOptions contains options for TargetState.Populate.
 type PopulateOptions struct {
 	ExecuteTemplates bool
+	// If TargetName is not empty then only source directories that are
+	// parents or children of TargetName are walked.
+	TargetName string
 }
 
 // A TargetState represents the root target state.
 type TargetState struct {
 	DestDir         string
 	Entries         map[string]Entry
+	EntryFilter     *EntryFilter
 	GPG             *GPG
+	Interpreters    map[string]Interpreter
 	MinVersion      *semver.Version
 	SourceDir       string
 	TargetIgnore    *PatternSet
@@ -63,7 +72,10 @@ type TargetState struct {
 	TemplateFuncs   template.FuncMap
 	TemplateOptions []string
 	Templates       map[string]*template.Template
+	Triggers        []*Trigger
 	Umask           os.FileMode
+	includeStack    []string
+	includedTargets map[string]struct{}
 }
 
 // A TargetStateOption sets an option on a TargeState.
@@ -83,6 +95,13 @@ func WithEntries(entries map[string]Entry) TargetStateOption {
 	}
 }
 
+// WithEntryFilter sets the entry filter.
+func WithEntryFilter(entryFilter *EntryFilter) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.EntryFilter = entryFilter
+	}
+}
+
 // WithGPG sets the GPG options.
 func WithGPG(gpg *GPG) TargetStateOption {
 	return func(ts *TargetState) {
@@ -90,6 +109,14 @@ func WithGPG(gpg *GPG) TargetStateOption {
 	}
 }
 
+// WithInterpreters sets the interpreters, indexed by file extension without
+// the leading dot.
+func WithInterpreters(interpreters map[string]Interpreter) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Interpreters = interpreters
+	}
+}
+
 // WithMinVersion sets the minimum version.
 func WithMinVersion(minVersion *semver.Version) TargetStateOption {
 	return func(ts *TargetState) {
@@ -311,7 +338,7 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 			for _, match := range matches {
 				relPath := strings.TrimPrefix(match, ts.DestDir+string(filepath.Separator))
 				// Don't remove targets that are ignored.
-				if ts.TargetIgnore.Match(relPath) {
+				if ts.Ignore(relPath) {
 					continue
 				}
 				// Don't remove targets that are excluded from remove.
@@ -355,7 +382,7 @@ func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
 	}
 
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, headerTemplate, umask); err != nil {
+		if err := ts.Entries[entryName].archive(w, ts.Ignore, headerTemplate, umask); err != nil {
 			return err
 		}
 	}
@@ -366,7 +393,7 @@ func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
 func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
 	var entryConcreteValues []interface{}
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, ts.Umask, recursive)
+		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.Ignore, ts.SourceDir, ts.Umask, recursive)
 		if err != nil {
 			return nil, err
 		}
@@ -380,7 +407,7 @@ func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
 // Evaluate evaluates all of the entries in ts.
 func (ts *TargetState) Evaluate() error {
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].Evaluate(ts.TargetIgnore.Match); err != nil {
+		if err := ts.Entries[entryName].Evaluate(ts.Ignore); err != nil {
 			return err
 		}
 	}
@@ -389,21 +416,11 @@ func (ts *TargetState) Evaluate() error {
 
 // ExecuteTemplateData returns the result of executing template data.
 func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, error) {
-	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	tmpl, err := ts.parseTemplate(name, data)
 	if err != nil {
 		return nil, err
 	}
-	for name, t := range ts.Templates {
-		tmpl, err = tmpl.AddParseTree(name, t.Tree)
-		if err != nil {
-			return nil, err
-		}
-	}
-	sb := &strings.Builder{}
-	if err = tmpl.ExecuteTemplate(sb, name, ts.TemplateData); err != nil {
-		return nil, err
-	}
-	return []byte(sb.String()), nil
+	return ts.executeTemplateTree(tmpl, name, ts.TemplateData)
 }
 
 // Get returns the state of the given target, or nil if no such target is found.
@@ -422,6 +439,110 @@ func (ts *TargetState) Get(fs vfs.Stater, target string) (Entry, error) {
 	return ts.findEntry(targetName)
 }
 
+// Ignore returns true if targetName is ignored, either by .chezmoiignore or by
+// ts.EntryFilter. Parent directories of entries included by ts.EntryFilter are
+// never ignored by it. Targets that are not entries, for example those removed
+// from exact directories, are only included if ts.EntryFilter includes
+// removes.
+func (ts *TargetState) Ignore(targetName string) bool {
+	if ts.TargetIgnore.Match(targetName) {
+		return true
+	}
+	if ts.EntryFilter == nil {
+		return false
+	}
+	if ts.includedTargets == nil {
+		ts.includedTargets = make(map[string]struct{})
+		walkEntries(ts.Entries, func(entry Entry) {
+			if !ts.EntryFilter.IncludeEntry(entry) {
+				return
+			}
+			for name := entry.TargetName(); name != "."; name = filepath.Dir(name) {
+				ts.includedTargets[name] = struct{}{}
+			}
+		})
+	}
+	if _, ok := ts.includedTargets[targetName]; ok {
+		return false
+	}
+	if _, err := ts.findEntry(targetName); err == nil {
+		return true
+	}
+	return !ts.EntryFilter.IncludeRemove(targetName)
+}
+
+// FindSourceEntry returns the entry for targetName in the source directory
+// sourceDir in fs. Unlike Populate, it only reads the source directories that
+// contain targetName, and it does not read special files or execute templates.
+// The contents of the returned entry are not read. If there is no entry for
+// targetName then it returns os.ErrNotExist.
+func FindSourceEntry(fs vfs.FS, sourceDir, targetName string) (Entry, error) {
+	var sourceNames []string
+	components := splitPathList(targetName)
+	for i, component := range components {
+		infos, err := fs.ReadDir(filepath.Join(append([]string{sourceDir}, sourceNames...)...))
+		if err != nil {
+			return nil, err
+		}
+		entryTargetName := filepath.Join(components[:i+1]...)
+		last := i == len(components)-1
+		var entry Entry
+	FOR:
+		for _, info := range infos {
+			if strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			sourceName := filepath.Join(append(sourceNames, info.Name())...)
+			switch {
+			case info.IsDir():
+				if da := ParseDirAttributes(info.Name()); da.Name == component {
+					entry = newDir(sourceName, entryTargetName, da.Exact, da.Perm)
+					break FOR
+				}
+			case last && info.Mode().IsRegular():
+				psfp := parseSourceFilePath(sourceName)
+				switch {
+				case psfp.scriptAttributes != nil && psfp.scriptAttributes.Name == component:
+					entry = &Script{
+						sourceName: sourceName,
+						targetName: entryTargetName,
+						Once:       psfp.scriptAttributes.Once,
+						Template:   psfp.scriptAttributes.Template,
+					}
+					break FOR
+				case psfp.fileAttributes != nil && psfp.fileAttributes.Name == component && psfp.fileAttributes.Mode&os.ModeType == os.ModeSymlink:
+					entry = &Symlink{
+						sourceName: sourceName,
+						targetName: entryTargetName,
+						Template:   psfp.fileAttributes.Template,
+					}
+					break FOR
+				case psfp.fileAttributes != nil && psfp.fileAttributes.Name == component:
+					entry = &File{
+						sourceName: sourceName,
+						targetName: entryTargetName,
+						Empty:      psfp.fileAttributes.Empty,
+						Encrypted:  psfp.fileAttributes.Encrypted,
+						Perm:       psfp.fileAttributes.Mode.Perm(),
+						Template:   psfp.fileAttributes.Template,
+					}
+					break FOR
+				}
+			}
+		}
+		switch _, isDir := entry.(*Dir); {
+		case entry == nil:
+			return nil, os.ErrNotExist
+		case last:
+			return entry, nil
+		case !isDir:
+			return nil, os.ErrNotExist
+		}
+		sourceNames = append(sourceNames, filepath.Base(entry.SourceName()))
+	}
+	return nil, os.ErrNotExist
+}
+
 // ImportTAR imports a tar archive.
 func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROptions, mutator Mutator) error {
 	for {
@@ -463,6 +584,9 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			case info.Name() == removeName:
 				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
 				return ts.addPatterns(fs, ts.TargetRemove, path, filepath.Join(dns...))
+			case info.Name() == triggersName:
+				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				return ts.addTriggers(fs, path, filepath.Join(dns...))
 			case info.Name() == templatesDirName:
 				if err := ts.addTemplatesDir(fs, path); err != nil {
 					return err
@@ -494,6 +618,9 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			das := parseDirNameComponents(components)
 			dns := dirNames(das)
 			targetName := filepath.Join(dns...)
+			if options != nil && options.TargetName != "" && !isParentOrSelf(targetName, options.TargetName) && !isParentOrSelf(options.TargetName, targetName) {
+				return filepath.SkipDir
+			}
 			entries, err := ts.findEntries(dns[:len(dns)-1])
 			if err != nil {
 				return err
@@ -553,6 +680,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						targetName:       filepath.Join(append(dns, psfp.scriptAttributes.Name)...),
 						Once:             psfp.scriptAttributes.Once,
 						Template:         psfp.scriptAttributes.Template,
+						Interpreter:      ts.interpreter(psfp.scriptAttributes.Name),
 						evaluateContents: evaluateContents,
 					}
 					entries[psfp.scriptAttributes.Name] = entry
@@ -752,7 +880,7 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 				return err
 			}
 			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
-			tmpl, err := template.New(name).Parse(string(contents))
+			tmpl, err := ts.parseTemplate(name, contents)
 			if err != nil {
 				return err
 			}
@@ -769,6 +897,85 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 	})
 }
 
+// executeTemplateTree executes the template name in tmpl with data, with all
+// templates in ts available.
+func (ts *TargetState) executeTemplateTree(tmpl *template.Template, name string, data interface{}) ([]byte, error) {
+	for templateName, t := range ts.Templates {
+		var err error
+		tmpl, err = tmpl.AddParseTree(templateName, t.Tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sb := &strings.Builder{}
+	if err := tmpl.ExecuteTemplate(sb, name, data); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+// includeTemplate is the includeTemplate template function. It returns the
+// result of executing the template name with data, or with the template data
+// if data is not given.
+func (ts *TargetState) includeTemplate(name string, data ...interface{}) (string, error) {
+	var templateData interface{} = ts.TemplateData
+	switch len(data) {
+	case 0:
+	case 1:
+		templateData = data[0]
+	default:
+		return "", fmt.Errorf("includeTemplate: expected 1 or 2 arguments, got %d", len(data)+1)
+	}
+	if _, ok := ts.Templates[name]; !ok {
+		return "", fmt.Errorf("%s: template not found", name)
+	}
+	if len(ts.includeStack) >= maxIncludeTemplateDepth {
+		chain := append(append([]string{}, ts.includeStack...), name)
+		return "", fmt.Errorf("includeTemplate: maximum depth %d exceeded: %s", maxIncludeTemplateDepth, strings.Join(chain, " -> "))
+	}
+	ts.includeStack = append(ts.includeStack, name)
+	defer func() {
+		ts.includeStack = ts.includeStack[:len(ts.includeStack)-1]
+	}()
+	output, err := ts.executeTemplateTree(ts.newTemplate(name), name, templateData)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// parseTemplate parses data as the template name, applying any template
+// directives in data.
+func (ts *TargetState) parseTemplate(name string, data []byte) (*template.Template, error) {
+	td, data, err := parseTemplateDirective(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return td.apply(ts.newTemplate(name)).Parse(string(data))
+}
+
+// interpreter returns the interpreter for the script name, or nil if the
+// script should be executed directly.
+func (ts *TargetState) interpreter(name string) *Interpreter {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	interpreter, ok := ts.Interpreters[ext]
+	if !ok || interpreter.Command == "" {
+		return nil
+	}
+	return &interpreter
+}
+
+// newTemplate returns a new template with name and all of ts's template
+// options and functions.
+func (ts *TargetState) newTemplate(name string) *template.Template {
+	return template.New(name).
+		Option(ts.TemplateOptions...).
+		Funcs(ts.TemplateFuncs).
+		Funcs(template.FuncMap{
+			"includeTemplate": ts.includeTemplate,
+		})
+}
+
 func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	data, err := fs.ReadFile(path)
 	if err != nil {
@@ -851,3 +1058,20 @@ func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptio
 		return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
 	}
 }
+
+// isParentOrSelf returns true if targetName is parentName or is in parentName.
+func isParentOrSelf(parentName, targetName string) bool {
+	return targetName == parentName || strings.HasPrefix(targetName, parentName+string(filepath.Separator))
+}
+
+// walkEntries calls f for every entry in entries and their descendants,
+// including scripts.
+func walkEntries(entries map[string]Entry, f func(Entry)) {
+	for _, entryName := range sortedEntryNames(entries) {
+		entry := entries[entryName]
+		f(entry)
+		if dir, ok := entry.(*Dir); ok {
+			walkEntries(dir.Entries, f)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package chezmoi
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"text/template"
 
@@ -127,6 +128,7 @@ func TestTargetStatePopulate(t *testing.T) {
 		sourceDir     string
 		data          map[string]interface{}
 		templateFuncs template.FuncMap
+		options       *PopulateOptions
 		want          *TargetState
 	}{
 		{
@@ -446,6 +448,44 @@ func TestTargetStatePopulate(t *testing.T) {
 				WithSourceDir("/"),
 			),
 		},
+		{
+			name: "target_name",
+			root: map[string]interface{}{
+				"/bar/baz": "qux",
+				"/dot_foo": "bar",
+				"/foo/bar": "baz",
+			},
+			sourceDir: "/",
+			options: &PopulateOptions{
+				ExecuteTemplates: true,
+				TargetName:       filepath.Join("foo", "bar"),
+			},
+			want: NewTargetState(
+				WithDestDir("/"),
+				WithEntries(map[string]Entry{
+					".foo": &File{
+						sourceName: "dot_foo",
+						targetName: ".foo",
+						Perm:       0o666,
+						contents:   []byte("bar"),
+					},
+					"foo": &Dir{
+						sourceName: "foo",
+						targetName: "foo",
+						Perm:       0o777,
+						Entries: map[string]Entry{
+							"bar": &File{
+								sourceName: filepath.Join("foo", "bar"),
+								targetName: filepath.Join("foo", "bar"),
+								Perm:       0o666,
+								contents:   []byte("baz"),
+							},
+						},
+					},
+				}),
+				WithSourceDir("/"),
+			),
+		},
 		{
 			name: "empty_template_dir",
 			root: map[string]interface{}{
@@ -482,9 +522,207 @@ func TestTargetStatePopulate(t *testing.T) {
 				WithTemplateData(tc.data),
 				WithTemplateFuncs(tc.templateFuncs),
 			)
-			assert.NoError(t, ts.Populate(fs, nil))
+			assert.NoError(t, ts.Populate(fs, tc.options))
 			assert.NoError(t, ts.Evaluate())
+			// Templates contain functions, which cannot be compared, so
+			// compare their sources instead.
+			assert.Equal(t, templateSources(tc.want.Templates), templateSources(ts.Templates))
+			tc.want.Templates, ts.Templates = nil, nil
 			assert.Equal(t, tc.want, ts)
 		})
 	}
 }
+
+func templateSources(templates map[string]*template.Template) map[string]string {
+	if templates == nil {
+		return nil
+	}
+	sources := make(map[string]string)
+	for name, tmpl := range templates {
+		sources[name] = tmpl.Tree.Root.String()
+	}
+	return sources
+}
+
+func TestTargetStateIncludeTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		templates   map[string]interface{}
+		text        string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "funcs",
+			templates: map[string]interface{}{
+				"upper": "{{ upper .name }}",
+			},
+			text:     `{{ template "upper" . }}`,
+			expected: "JOHN",
+		},
+		{
+			name: "data",
+			templates: map[string]interface{}{
+				"greeting": "Hello, {{ .name }}!",
+			},
+			text:     `{{ includeTemplate "greeting" (dict "name" "world") }} {{ includeTemplate "greeting" }}`,
+			expected: "Hello, world! Hello, john!",
+		},
+		{
+			name: "nested",
+			templates: map[string]interface{}{
+				"inner": "<{{ . }}>",
+				"outer": `{{ includeTemplate "inner" (upper .) }}`,
+			},
+			text:     `{{ includeTemplate "outer" "x" }}`,
+			expected: "<X>",
+		},
+		{
+			name:        "not_found",
+			text:        `{{ includeTemplate "missing" }}`,
+			expectedErr: "missing: template not found",
+		},
+		{
+			name: "recursive",
+			templates: map[string]interface{}{
+				"tree": `{{ .name }}{{ range index . "children" }}({{ template "tree" . }}){{ end }}`,
+			},
+			text:     `{{ includeTemplate "tree" }}`,
+			expected: "john",
+		},
+		{
+			name: "unused_cycle",
+			templates: map[string]interface{}{
+				"a": `{{ template "b" . }}`,
+				"b": `{{ includeTemplate "a" . }}`,
+			},
+			text:     "ok",
+			expected: "ok",
+		},
+		{
+			name: "cycle",
+			templates: map[string]interface{}{
+				"a": `{{ includeTemplate (print "b") . }}`,
+				"b": `{{ includeTemplate (print "a") . }}`,
+			},
+			text:        `{{ includeTemplate "a" }}`,
+			expectedErr: "includeTemplate: maximum depth 100 exceeded: a -> b -> a -> b",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoitemplates": tc.templates,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithTemplateData(map[string]interface{}{
+					"name": "john",
+				}),
+				WithTemplateFuncs(template.FuncMap{
+					"dict": func(key string, value interface{}) map[string]interface{} {
+						return map[string]interface{}{key: value}
+					},
+					"upper": strings.ToUpper,
+				}),
+			)
+			err = ts.Populate(fs, nil)
+			if err == nil {
+				var actual []byte
+				actual, err = ts.ExecuteTemplateData(tc.name, []byte(tc.text))
+				if err == nil {
+					assert.Equal(t, tc.expected, string(actual))
+				}
+			}
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestTargetStateInterpreter(t *testing.T) {
+	ts := NewTargetState(
+		WithInterpreters(map[string]Interpreter{
+			"py": {
+				Command: "python3",
+			},
+			"rb": {
+				Command: "ruby",
+				Args:    []string{"-w"},
+			},
+			"sh": {},
+		}),
+	)
+	assert.Equal(t, &Interpreter{Command: "python3"}, ts.interpreter("script.py"))
+	assert.Equal(t, &Interpreter{Command: "python3"}, ts.interpreter("script.PY"))
+	assert.Equal(t, &Interpreter{Command: "ruby", Args: []string{"-w"}}, ts.interpreter("dir/script.rb"))
+	assert.Nil(t, ts.interpreter("script.sh"))
+	assert.Nil(t, ts.interpreter("script"))
+}
+
+func TestFindSourceEntry(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiignore":                 "{{ fail \"not executed\" }}",
+			"dot_bashrc.tmpl":                "# contents of .bashrc",
+			"exact_private_dot_ssh/config":   "# contents of .ssh/config",
+			"private_dot_netrc":              "# contents of .netrc",
+			"run_once_script.sh":             "#!/bin/sh",
+			"symlink_dot_link":               ".bashrc",
+			"dot_config/app/executable_tool": "#!/bin/sh",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		targetName         string
+		expectedSourceName string
+	}{
+		{
+			targetName:         ".bashrc",
+			expectedSourceName: "dot_bashrc.tmpl",
+		},
+		{
+			targetName:         ".ssh",
+			expectedSourceName: "exact_private_dot_ssh",
+		},
+		{
+			targetName:         filepath.Join(".ssh", "config"),
+			expectedSourceName: filepath.Join("exact_private_dot_ssh", "config"),
+		},
+		{
+			targetName:         ".netrc",
+			expectedSourceName: "private_dot_netrc",
+		},
+		{
+			targetName:         "script.sh",
+			expectedSourceName: "run_once_script.sh",
+		},
+		{
+			targetName:         ".link",
+			expectedSourceName: "symlink_dot_link",
+		},
+		{
+			targetName:         filepath.Join(".config", "app", "tool"),
+			expectedSourceName: filepath.Join("dot_config", "app", "executable_tool"),
+		},
+	} {
+		t.Run(tc.targetName, func(t *testing.T) {
+			entry, err := FindSourceEntry(fs, "/home/user/.local/share/chezmoi", tc.targetName)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSourceName, entry.SourceName())
+			assert.Equal(t, tc.targetName, entry.TargetName())
+		})
+	}
+
+	for _, targetName := range []string{".missing", filepath.Join(".bashrc", "child"), filepath.Join(".missing", "child")} {
+		_, err := FindSourceEntry(fs, "/home/user/.local/share/chezmoi", targetName)
+		assert.True(t, os.IsNotExist(err), targetName)
+	}
+}
@@ -0,0 +1,85 @@
+package chezmoi
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+)
+
+var (
+	templateDirectiveRegexp         = regexp.MustCompile(`(?m)^.*chezmoi:template:(.*)$(?:\r?\n)?`)
+	templateDirectiveKeyValueRegexp = regexp.MustCompile(`\s*([0-9A-Za-z-]+)=("(?:[^"\\]|\\.)*"|\S+)`)
+)
+
+// A templateDirective contains the per-file template settings parsed from
+// chezmoi:template: directives.
+type templateDirective struct {
+	leftDelimiter  string
+	rightDelimiter string
+	options        []string
+	removedLines   []int
+}
+
+// parseTemplateDirective parses and removes all lines containing
+// chezmoi:template: directives from data.
+func parseTemplateDirective(data []byte) (*templateDirective, []byte, error) {
+	matches := templateDirectiveRegexp.FindAllSubmatchIndex(data, -1)
+	if matches == nil {
+		return &templateDirective{}, data, nil
+	}
+
+	td := &templateDirective{}
+	result := make([]byte, 0, len(data))
+	prevEnd := 0
+	for _, match := range matches {
+		result = append(result, data[prevEnd:match[0]]...)
+		prevEnd = match[1]
+		td.removedLines = append(td.removedLines, 1+bytes.Count(data[:match[0]], []byte("\n")))
+		for _, kv := range templateDirectiveKeyValueRegexp.FindAllSubmatch(data[match[2]:match[3]], -1) {
+			key := string(kv[1])
+			value := string(kv[2])
+			if len(value) >= 2 && value[0] == '"' {
+				var err error
+				value, err = strconv.Unquote(value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			switch key {
+			case "left-delimiter":
+				td.leftDelimiter = value
+			case "right-delimiter":
+				td.rightDelimiter = value
+			case "missing-key":
+				switch value {
+				case "default", "invalid", "zero", "error":
+					td.options = append(td.options, "missingkey="+value)
+				default:
+					return nil, nil, fmt.Errorf("%s: invalid value for %s", value, key)
+				}
+			default:
+				return nil, nil, fmt.Errorf("%s: unknown template directive", key)
+			}
+		}
+	}
+	result = append(result, data[prevEnd:]...)
+	return td, result, nil
+}
+
+// apply applies td to tmpl.
+func (td *templateDirective) apply(tmpl *template.Template) *template.Template {
+	return tmpl.Delims(td.leftDelimiter, td.rightDelimiter).Option(td.options...)
+}
+
+// fileLine returns the line number in the original data of line in the data
+// with directives removed.
+func (td *templateDirective) fileLine(line int) int {
+	for _, removedLine := range td.removedLines {
+		if removedLine <= line {
+			line++
+		}
+	}
+	return line
+}
@@ -0,0 +1,105 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateDirective(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expected      *templateDirective
+		expectedData  string
+		expectedError bool
+	}{
+		{
+			name:         "none",
+			data:         "{{ .foo }}\n",
+			expected:     &templateDirective{},
+			expectedData: "{{ .foo }}\n",
+		},
+		{
+			name: "delimiters",
+			data: "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n[[ .foo ]]\n",
+			expected: &templateDirective{
+				leftDelimiter:  "[[",
+				rightDelimiter: "]]",
+				removedLines:   []int{1},
+			},
+			expectedData: "[[ .foo ]]\n",
+		},
+		{
+			name: "quoted",
+			data: "line1\n<!-- chezmoi:template:left-delimiter=\"<< \" right-delimiter=\" >>\" missing-key=zero -->\nline3",
+			expected: &templateDirective{
+				leftDelimiter:  "<< ",
+				rightDelimiter: " >>",
+				options:        []string{"missingkey=zero"},
+				removedLines:   []int{2},
+			},
+			expectedData: "line1\nline3",
+		},
+		{
+			name: "multiple",
+			data: "# chezmoi:template:left-delimiter=[[\n# chezmoi:template:right-delimiter=]]\n",
+			expected: &templateDirective{
+				leftDelimiter:  "[[",
+				rightDelimiter: "]]",
+				removedLines:   []int{1, 2},
+			},
+			expectedData: "",
+		},
+		{
+			name:          "unknown_key",
+			data:          "# chezmoi:template:foo=bar\n",
+			expectedError: true,
+		},
+		{
+			name:          "invalid_missing_key",
+			data:          "# chezmoi:template:missing-key=maybe\n",
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, actualData, err := parseTemplateDirective([]byte(tc.data))
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedData, string(actualData))
+		})
+	}
+}
+
+func TestTemplateDirectiveFileLine(t *testing.T) {
+	td := &templateDirective{
+		removedLines: []int{1, 2, 5},
+	}
+	assert.Equal(t, 3, td.fileLine(1))
+	assert.Equal(t, 4, td.fileLine(2))
+	assert.Equal(t, 6, td.fileLine(3))
+}
+
+func TestExecuteTemplateDataDirectives(t *testing.T) {
+	ts := NewTargetState(
+		WithTemplateData(map[string]interface{}{
+			"foo": "bar",
+		}),
+	)
+
+	actual, err := ts.ExecuteTemplateData("delimiters", []byte("# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n{{ .foo }} [[ .foo ]]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .foo }} bar\n", string(actual))
+
+	_, err = ts.ExecuteTemplateData("missingkey", []byte("{{ .missing }}"))
+	assert.Error(t, err)
+
+	actual, err = ts.ExecuteTemplateData("missingkey", []byte("{{ .missing }}\n# chezmoi:template:missing-key=zero\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "<no value>\n", string(actual))
+}
@@ -0,0 +1,177 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+const (
+	triggersName        = ".chezmoitriggers"
+	triggerScriptPrefix = "script:"
+)
+
+// A Trigger is a command or script that is run when a target matching Pattern
+// is changed.
+type Trigger struct {
+	Pattern string
+	Name    string
+	script  *Script
+}
+
+// Apply runs t.
+func (t *Trigger) Apply(applyOptions *ApplyOptions) error {
+	contents, err := t.script.Contents()
+	if err != nil {
+		return err
+	}
+	if applyOptions.Verbose {
+		if _, err := fmt.Fprintf(applyOptions.Stdout, "trigger: %s\n", t.Name); err != nil {
+			return err
+		}
+	}
+	if applyOptions.DryRun {
+		return nil
+	}
+	if err := t.script.run("trigger "+t.Name, applyOptions.DestDir, contents, applyOptions); err != nil {
+		if applyOptions.ScriptError != nil {
+			return applyOptions.ScriptError(t.script, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Match returns true if t should be run when target is changed. A target
+// matches if it or any of its parent directories matches t's pattern.
+func (t *Trigger) Match(target string) bool {
+	for ; target != "." && target != string(filepath.Separator); target = filepath.Dir(target) {
+		if ok, _ := doublestar.PathMatch(t.Pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Triggered returns the triggers in ts that should be run when changed are
+// changed, in the order in which they were defined. Triggers with the same
+// name are only returned once.
+func (ts *TargetState) Triggered(changed []string) []*Trigger {
+	var triggered []*Trigger
+	names := make(map[string]bool)
+	for _, t := range ts.Triggers {
+		if names[t.Name] {
+			continue
+		}
+		for _, target := range changed {
+			if t.Match(target) {
+				triggered = append(triggered, t)
+				names[t.Name] = true
+				break
+			}
+		}
+	}
+	return triggered
+}
+
+// addTriggers adds the triggers defined in the .chezmoitriggers file at path.
+// Patterns are relative to relPath, the target directory containing the file.
+func (ts *TargetState) addTriggers(fs vfs.FS, path, relPath string) error {
+	data, err := ts.executeTemplate(fs, path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(relPath)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: missing command", path, lineNumber)
+		}
+		pattern := filepath.Join(dir, fields[0])
+		if _, err := doublestar.PathMatch(pattern, ""); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		action := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		var t *Trigger
+		if strings.HasPrefix(action, triggerScriptPrefix) {
+			t, err = ts.newScriptTrigger(fs, filepath.Join(filepath.Dir(path), strings.TrimPrefix(action, triggerScriptPrefix)))
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+		} else {
+			t = newCommandTrigger(action)
+		}
+		t.Pattern = pattern
+		ts.Triggers = append(ts.Triggers, t)
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// newCommandTrigger returns a new Trigger that runs command with the system
+// shell.
+func newCommandTrigger(command string) *Trigger {
+	script := &Script{
+		targetName: "trigger.sh",
+		Interpreter: &Interpreter{
+			Command: "sh",
+		},
+		contents: []byte(command + "\n"),
+	}
+	if runtime.GOOS == "windows" {
+		script.targetName = "trigger.bat"
+		script.Interpreter = &Interpreter{
+			Command: "cmd",
+			Args:    []string{"/c"},
+		}
+	}
+	return &Trigger{
+		Name:   command,
+		script: script,
+	}
+}
+
+// newScriptTrigger returns a new Trigger that runs the script at path in the
+// source directory. Scripts with the .tmpl suffix are executed as templates.
+func (ts *TargetState) newScriptTrigger(fs vfs.FS, path string) (*Trigger, error) {
+	name, err := filepath.Rel(ts.SourceDir, path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(name, "..") {
+		return nil, fmt.Errorf("%s: not in source directory", path)
+	}
+	if _, err := fs.Stat(path); err != nil {
+		return nil, err
+	}
+	template := strings.HasSuffix(path, TemplateSuffix)
+	targetName := strings.TrimSuffix(filepath.Base(path), TemplateSuffix)
+	return &Trigger{
+		Name: name,
+		script: &Script{
+			sourceName:  name,
+			targetName:  targetName,
+			Template:    template,
+			Interpreter: ts.interpreter(targetName),
+			evaluateContents: func() ([]byte, error) {
+				if template {
+					return ts.executeTemplate(fs, path)
+				}
+				return fs.ReadFile(path)
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,65 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetStateTriggered(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitriggers": "# comment\n" +
+				".tmux.conf tmux source-file ~/.tmux.conf\n" +
+				".local/share/fonts fc-cache\n" +
+				".config/systemd/user/*.service systemctl --user daemon-reload\n" +
+				"**/*.ttf fc-cache\n",
+			"dot_config/systemd/user/.chezmoitriggers": "*.timer systemctl --user daemon-reload\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	triggeredNames := func(changed ...string) []string {
+		var names []string
+		for _, trigger := range ts.Triggered(changed) {
+			names = append(names, trigger.Name)
+		}
+		return names
+	}
+	assert.Nil(t, triggeredNames())
+	assert.Nil(t, triggeredNames(".bashrc", ".local/share"))
+	assert.Equal(t, []string{"tmux source-file ~/.tmux.conf"}, triggeredNames(".tmux.conf"))
+	assert.Equal(t, []string{"fc-cache"}, triggeredNames(".local/share/fonts/font.ttf", "dir/font.ttf"))
+	assert.Equal(t, []string{"systemctl --user daemon-reload"}, triggeredNames(".config/systemd/user/a.timer"))
+	assert.Equal(t, []string{"tmux source-file ~/.tmux.conf", "systemctl --user daemon-reload"}, triggeredNames(".config/systemd/user/a.service", ".tmux.conf"))
+}
+
+func TestTargetStateTriggersErrors(t *testing.T) {
+	for name, contents := range map[string]string{
+		"missing_command": ".tmux.conf\n",
+		"missing_script":  ".tmux.conf script:.chezmoiscripts/missing.sh\n",
+		"outside_source":  ".tmux.conf script:../script.sh\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoitriggers": contents,
+				"/home/user/.local/share/script.sh":                "#!/bin/sh\n",
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			assert.Error(t, ts.Populate(fs, nil))
+		})
+	}
+}
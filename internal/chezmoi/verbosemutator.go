@@ -1,9 +1,6 @@
 package chezmoi
 
 import (
-	"bufio"
-	"bytes"
-	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,8 +8,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
-
-	"github.com/pkg/diff"
 )
 
 // A VerboseMutator wraps an Mutator and logs all of the actions it executes and
@@ -125,26 +120,7 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 				return nil
 			}
 		}
-		aLines, err := splitLines(currData)
-		if err != nil {
-			return err
-		}
-		bLines, err := splitLines(data)
-		if err != nil {
-			return err
-		}
-		ab := diff.Strings(aLines, bLines)
-		e := diff.Myers(context.Background(), ab).WithContextSize(3)
-		opts := []diff.WriteOpt{
-			diff.Names(
-				filepath.Join("a", name),
-				filepath.Join("b", name),
-			),
-		}
-		if m.colored {
-			opts = append(opts, diff.TerminalColor())
-		}
-		if _, err := e.WriteUnified(m.w, ab, opts...); err != nil {
+		if err := WriteUnifiedDiff(m.w, filepath.Join("a", name), filepath.Join("b", name), currData, data, m.colored); err != nil {
 			return err
 		}
 	} else {
@@ -177,12 +153,3 @@ func cmdString(cmd *exec.Cmd) string {
 func isBinary(data []byte) bool {
 	return len(data) != 0 && !strings.HasPrefix(http.DetectContentType(data), "text/")
 }
-
-func splitLines(data []byte) ([]string, error) {
-	var lines []string
-	s := bufio.NewScanner(bytes.NewReader(data))
-	for s.Scan() {
-		lines = append(lines, s.Text())
-	}
-	return lines, s.Err()
-}
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// base85Alphabet is the alphabet used by git to encode binary patches.
+const base85Alphabet = "0123456789" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"!#$%&()*+-;<=>?@^_`{|}~"
+
+// maxBinaryPatchLineBytes is the maximum number of bytes encoded on each line
+// of a binary patch.
+const maxBinaryPatchLineBytes = 52
+
+var base85Values = func() [256]int {
+	var values [256]int
+	for i := range values {
+		values[i] = -1
+	}
+	for i := 0; i < len(base85Alphabet); i++ {
+		values[base85Alphabet[i]] = i
+	}
+	return values
+}()
+
+// WriteBinaryLiteral writes data to w as a literal hunk of a git binary
+// patch, followed by a blank line.
+func WriteBinaryLiteral(w io.Writer, data []byte) error {
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "literal %d\n", len(data))
+	for rest := compressed.Bytes(); len(rest) > 0; {
+		n := len(rest)
+		if n > maxBinaryPatchLineBytes {
+			n = maxBinaryPatchLineBytes
+		}
+		if n <= 26 {
+			b.WriteByte(byte('A' + n - 1))
+		} else {
+			b.WriteByte(byte('a' + n - 27))
+		}
+		b.Write(encodeBase85(rest[:n]))
+		b.WriteByte('\n')
+		rest = rest[n:]
+	}
+	b.WriteByte('\n')
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// decodeBinaryLiteral decodes the lines of a literal hunk of a git binary
+// patch whose uncompressed size is size.
+func decodeBinaryLiteral(lines []string, size int) ([]byte, error) {
+	compressed := &bytes.Buffer{}
+	for _, line := range lines {
+		if len(line) < 6 || (len(line)-1)%5 != 0 {
+			return nil, fmt.Errorf("%q: invalid binary patch line", line)
+		}
+		var n int
+		switch c := line[0]; {
+		case 'A' <= c && c <= 'Z':
+			n = int(c-'A') + 1
+		case 'a' <= c && c <= 'z':
+			n = int(c-'a') + 27
+		default:
+			return nil, fmt.Errorf("%q: invalid binary patch line length", line)
+		}
+		data, err := decodeBase85(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n > len(data) {
+			return nil, fmt.Errorf("%q: invalid binary patch line length", line)
+		}
+		compressed.Write(data[:n])
+	}
+	zr, err := zlib.NewReader(compressed)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("binary patch has size %d, expected %d", len(data), size)
+	}
+	return data, nil
+}
+
+// encodeBase85 encodes data, padded with zeros to a multiple of four bytes,
+// using git's base85 encoding.
+func encodeBase85(data []byte) []byte {
+	encoded := make([]byte, 0, (len(data)+3)/4*5)
+	for i := 0; i < len(data); i += 4 {
+		var value uint32
+		for j := 0; j < 4; j++ {
+			value <<= 8
+			if i+j < len(data) {
+				value |= uint32(data[i+j])
+			}
+		}
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = base85Alphabet[value%85]
+			value /= 85
+		}
+		encoded = append(encoded, chunk[:]...)
+	}
+	return encoded
+}
+
+// decodeBase85 decodes s, which must be a multiple of five characters long,
+// using git's base85 encoding.
+func decodeBase85(s string) ([]byte, error) {
+	if len(s)%5 != 0 {
+		return nil, errors.New("invalid base85 length")
+	}
+	data := make([]byte, 0, len(s)/5*4)
+	for i := 0; i < len(s); i += 5 {
+		var value uint64
+		for j := 0; j < 5; j++ {
+			digit := base85Values[s[i+j]]
+			if digit < 0 {
+				return nil, fmt.Errorf("%q: invalid base85 character", s[i+j])
+			}
+			value = value*85 + uint64(digit)
+		}
+		if value > 0xffffffff {
+			return nil, errors.New("invalid base85 value")
+		}
+		data = append(data, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+	}
+	return data, nil
+}
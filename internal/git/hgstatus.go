@@ -0,0 +1,46 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// ParseHgStatus parses the output of
+//   hg status
+// into a Status, so that Mercurial changes can be described in the same way as
+// git changes. See https://www.mercurial-scm.org/doc/hg.1.html#status.
+func ParseHgStatus(output []byte) (*Status, error) {
+	status := &Status{}
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		text := s.Text()
+		if len(text) < 3 || text[1] != ' ' {
+			return nil, ParseError(text)
+		}
+		path := text[2:]
+		switch text[0] {
+		case 'A':
+			status.Ordinary = append(status.Ordinary, OrdinaryStatus{X: 'A', Y: '.', Path: path})
+		case 'C':
+		case 'I':
+			status.Ignored = append(status.Ignored, IgnoredStatus{Path: path})
+		case 'M':
+			status.Ordinary = append(status.Ordinary, OrdinaryStatus{X: 'M', Y: '.', Path: path})
+		case 'R':
+			status.Ordinary = append(status.Ordinary, OrdinaryStatus{X: 'D', Y: '.', Path: path})
+		case '!':
+			status.Ordinary = append(status.Ordinary, OrdinaryStatus{X: '.', Y: 'D', Path: path})
+		case '?':
+			status.Untracked = append(status.Untracked, UntrackedStatus{Path: path})
+		default:
+			return nil, ParseError(text)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if status.Empty() {
+		return nil, nil
+	}
+	return status, nil
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHgStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		outputStr      string
+		expectedEmpty  bool
+		expectedStatus *Status
+	}{
+		{
+			name:          "empty",
+			outputStr:     "",
+			expectedEmpty: true,
+		},
+		{
+			name:      "added",
+			outputStr: "A dot_bashrc\n",
+			expectedStatus: &Status{
+				Ordinary: []OrdinaryStatus{
+					{
+						X:    'A',
+						Y:    '.',
+						Path: "dot_bashrc",
+					},
+				},
+			},
+		},
+		{
+			name:      "modified_and_removed",
+			outputStr: "M dot_bashrc\nR dot_profile\n",
+			expectedStatus: &Status{
+				Ordinary: []OrdinaryStatus{
+					{
+						X:    'M',
+						Y:    '.',
+						Path: "dot_bashrc",
+					},
+					{
+						X:    'D',
+						Y:    '.',
+						Path: "dot_profile",
+					},
+				},
+			},
+		},
+		{
+			name:      "missing",
+			outputStr: "! dot_bashrc\n",
+			expectedStatus: &Status{
+				Ordinary: []OrdinaryStatus{
+					{
+						X:    '.',
+						Y:    'D',
+						Path: "dot_bashrc",
+					},
+				},
+			},
+		},
+		{
+			name:      "untracked_and_ignored",
+			outputStr: "? dot_bashrc\nI dot_profile\n",
+			expectedStatus: &Status{
+				Untracked: []UntrackedStatus{
+					{
+						Path: "dot_bashrc",
+					},
+				},
+				Ignored: []IgnoredStatus{
+					{
+						Path: "dot_profile",
+					},
+				},
+			},
+		},
+		{
+			name:          "clean",
+			outputStr:     "C dot_bashrc\n",
+			expectedEmpty: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualStatus, err := ParseHgStatus([]byte(tc.outputStr))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedEmpty, actualStatus.Empty())
+			assert.Equal(t, tc.expectedStatus, actualStatus)
+		})
+	}
+}
+
+func TestParseHgStatusError(t *testing.T) {
+	_, err := ParseHgStatus([]byte("X dot_bashrc\n"))
+	assert.Error(t, err)
+}
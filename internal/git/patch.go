@@ -0,0 +1,420 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+var (
+	fragmentHeaderRegexp = regexp.MustCompile(`\A@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	indexRegexp          = regexp.MustCompile(`\A([0-9a-f]+)\.\.([0-9a-f]+)(?: ([0-7]+))?\z`)
+)
+
+// A FilePatch is the patch to a single file in a git patch.
+type FilePatch struct {
+	OldPath        string
+	NewPath        string
+	OldMode        filemode.FileMode
+	NewMode        filemode.FileMode
+	OldHash        string
+	NewHash        string
+	IsNew          bool
+	IsDelete       bool
+	IsRename       bool
+	IsBinary       bool
+	Fragments      []*TextFragment
+	BinaryFragment []byte
+	hasBinaryData  bool
+}
+
+// A TextFragment is a hunk of a text patch.
+type TextFragment struct {
+	OldPosition int
+	OldLines    int
+	NewPosition int
+	NewLines    int
+	Lines       []FragmentLine
+}
+
+// A FragmentLine is a single line of a TextFragment. Line includes the
+// trailing newline, if any.
+type FragmentLine struct {
+	Op   byte
+	Line string
+}
+
+// ParsePatch parses the git patch in r. Any text before the first file patch,
+// for example a commit message, is ignored.
+func ParsePatch(r io.Reader) ([]*FilePatch, error) {
+	p := &patchParser{
+		s: bufio.NewScanner(r),
+	}
+	p.s.Buffer(nil, 64*1024*1024)
+	return p.parse()
+}
+
+// Apply returns the result of applying p to old, the contents of the file
+// before the patch. For symlinks, the contents are the link target.
+func (p *FilePatch) Apply(old []byte) ([]byte, error) {
+	if p.OldHash != "" && !IsZeroHash(p.OldHash) {
+		if hash := plumbing.ComputeHash(plumbing.BlobObject, old).String(); !strings.HasPrefix(hash, p.OldHash) {
+			return nil, fmt.Errorf("%s: contents do not match index %s", p.OldPath, p.OldHash)
+		}
+	}
+	switch {
+	case p.IsDelete:
+		return nil, nil
+	case p.IsBinary && !p.hasBinaryData:
+		return nil, fmt.Errorf("%s: binary patch without data", p.NewPath)
+	case p.IsBinary:
+		return p.BinaryFragment, nil
+	default:
+		return applyTextFragments(p.OldPath, old, p.Fragments)
+	}
+}
+
+// IsZeroHash returns true if hash, which may be abbreviated, is the zero hash
+// that git uses for files that do not exist.
+func IsZeroHash(hash string) bool {
+	return strings.Trim(hash, "0") == ""
+}
+
+// applyTextFragments applies fragments to old. The context of each fragment
+// must match exactly at its position.
+func applyTextFragments(name string, old []byte, fragments []*TextFragment) ([]byte, error) {
+	oldLines := splitLinesKeepEnds(old)
+	sb := &strings.Builder{}
+	i := 0
+	for _, fragment := range fragments {
+		start := fragment.OldPosition - 1
+		if fragment.OldLines == 0 {
+			start = fragment.OldPosition
+		}
+		if start < i || start > len(oldLines) {
+			return nil, fmt.Errorf("%s: hunk at line %d does not apply", name, fragment.OldPosition)
+		}
+		for ; i < start; i++ {
+			sb.WriteString(oldLines[i])
+		}
+		for _, line := range fragment.Lines {
+			switch line.Op {
+			case ' ', '-':
+				if i >= len(oldLines) || oldLines[i] != line.Line {
+					return nil, fmt.Errorf("%s: hunk at line %d does not apply", name, fragment.OldPosition)
+				}
+				i++
+				if line.Op == ' ' {
+					sb.WriteString(line.Line)
+				}
+			case '+':
+				sb.WriteString(line.Line)
+			}
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		sb.WriteString(oldLines[i])
+	}
+	return []byte(sb.String()), nil
+}
+
+// A patchParser parses a git patch.
+type patchParser struct {
+	s       *bufio.Scanner
+	line    string
+	lineNum int
+	eof     bool
+}
+
+func (p *patchParser) parse() ([]*FilePatch, error) {
+	var filePatches []*FilePatch
+	p.next()
+	for !p.eof {
+		if !strings.HasPrefix(p.line, "diff --git ") {
+			p.next()
+			continue
+		}
+		filePatch, err := p.parseFilePatch()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.lineNum, err)
+		}
+		filePatches = append(filePatches, filePatch)
+	}
+	if err := p.s.Err(); err != nil {
+		return nil, err
+	}
+	return filePatches, nil
+}
+
+func (p *patchParser) next() {
+	if p.s.Scan() {
+		p.line = p.s.Text()
+		p.lineNum++
+	} else {
+		p.line = ""
+		p.eof = true
+	}
+}
+
+func (p *patchParser) parseFilePatch() (*FilePatch, error) {
+	oldPath, newPath, err := parseDiffGitLine(strings.TrimPrefix(p.line, "diff --git "))
+	if err != nil {
+		return nil, err
+	}
+	filePatch := &FilePatch{
+		OldPath: oldPath,
+		NewPath: newPath,
+	}
+	p.next()
+
+	// Parse the extended header lines.
+HEADER:
+	for ; !p.eof; p.next() {
+		switch {
+		case strings.HasPrefix(p.line, "old mode "):
+			filePatch.OldMode, err = parseMode(strings.TrimPrefix(p.line, "old mode "))
+		case strings.HasPrefix(p.line, "new mode "):
+			filePatch.NewMode, err = parseMode(strings.TrimPrefix(p.line, "new mode "))
+		case strings.HasPrefix(p.line, "deleted file mode "):
+			filePatch.IsDelete = true
+			filePatch.OldMode, err = parseMode(strings.TrimPrefix(p.line, "deleted file mode "))
+		case strings.HasPrefix(p.line, "new file mode "):
+			filePatch.IsNew = true
+			filePatch.NewMode, err = parseMode(strings.TrimPrefix(p.line, "new file mode "))
+		case strings.HasPrefix(p.line, "rename from "):
+			filePatch.IsRename = true
+			filePatch.OldPath, err = unquotePath(strings.TrimPrefix(p.line, "rename from "))
+		case strings.HasPrefix(p.line, "rename to "):
+			filePatch.IsRename = true
+			filePatch.NewPath, err = unquotePath(strings.TrimPrefix(p.line, "rename to "))
+		case strings.HasPrefix(p.line, "copy from "), strings.HasPrefix(p.line, "copy to "):
+			err = errors.New("copies are not supported")
+		case strings.HasPrefix(p.line, "similarity index "), strings.HasPrefix(p.line, "dissimilarity index "):
+		case strings.HasPrefix(p.line, "index "):
+			m := indexRegexp.FindStringSubmatch(strings.TrimPrefix(p.line, "index "))
+			if m == nil {
+				err = fmt.Errorf("%q: invalid index line", p.line)
+				break
+			}
+			filePatch.OldHash, filePatch.NewHash = m[1], m[2]
+			if m[3] != "" {
+				filePatch.OldMode, err = parseMode(m[3])
+				filePatch.NewMode = filePatch.OldMode
+			}
+		case strings.HasPrefix(p.line, "--- "):
+		case strings.HasPrefix(p.line, "+++ "):
+		case strings.HasPrefix(p.line, "Binary files "):
+			filePatch.IsBinary = true
+		default:
+			break HEADER
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if filePatch.OldMode == filemode.Empty && !filePatch.IsNew {
+		filePatch.OldMode = filePatch.NewMode
+	}
+	if filePatch.NewMode == filemode.Empty && !filePatch.IsDelete {
+		filePatch.NewMode = filePatch.OldMode
+	}
+	if filePatch.OldMode == filemode.Empty && filePatch.NewMode == filemode.Empty {
+		filePatch.OldMode, filePatch.NewMode = filemode.Regular, filemode.Regular
+	}
+
+	switch {
+	case p.line == "GIT binary patch":
+		filePatch.IsBinary = true
+		if err := p.parseBinaryPatch(filePatch); err != nil {
+			return nil, err
+		}
+	default:
+		for !p.eof && strings.HasPrefix(p.line, "@@ ") {
+			fragment, err := p.parseTextFragment()
+			if err != nil {
+				return nil, err
+			}
+			filePatch.Fragments = append(filePatch.Fragments, fragment)
+		}
+	}
+	return filePatch, nil
+}
+
+// parseBinaryPatch parses the forward and optional reverse hunks of a binary
+// patch. Only literal hunks are supported.
+func (p *patchParser) parseBinaryPatch(filePatch *FilePatch) error {
+	p.next()
+	for hunk := 0; hunk < 2 && !p.eof; hunk++ {
+		var size int
+		switch {
+		case strings.HasPrefix(p.line, "literal "):
+			var err error
+			if size, err = strconv.Atoi(strings.TrimPrefix(p.line, "literal ")); err != nil {
+				return fmt.Errorf("%q: invalid binary hunk", p.line)
+			}
+		case strings.HasPrefix(p.line, "delta "):
+			return errors.New("binary delta patches are not supported")
+		default:
+			if hunk == 0 {
+				return fmt.Errorf("%q: invalid binary hunk", p.line)
+			}
+			return nil
+		}
+		p.next()
+		var lines []string
+		for ; !p.eof && p.line != ""; p.next() {
+			lines = append(lines, p.line)
+		}
+		p.next()
+		// Only the forward hunk is needed to apply the patch.
+		if hunk == 0 {
+			data, err := decodeBinaryLiteral(lines, size)
+			if err != nil {
+				return err
+			}
+			filePatch.BinaryFragment = data
+			filePatch.hasBinaryData = true
+		}
+	}
+	return nil
+}
+
+func (p *patchParser) parseTextFragment() (*TextFragment, error) {
+	m := fragmentHeaderRegexp.FindStringSubmatch(p.line)
+	if m == nil {
+		return nil, fmt.Errorf("%q: invalid hunk header", p.line)
+	}
+	fragment := &TextFragment{
+		OldPosition: atoiOrDefault(m[1], 0),
+		OldLines:    atoiOrDefault(m[2], 1),
+		NewPosition: atoiOrDefault(m[3], 0),
+		NewLines:    atoiOrDefault(m[4], 1),
+	}
+	p.next()
+	oldLines, newLines := 0, 0
+	for !p.eof && (oldLines < fragment.OldLines || newLines < fragment.NewLines) {
+		if p.line == "" {
+			// Some editors strip the trailing space from empty context lines.
+			p.line = " "
+		}
+		switch op := p.line[0]; op {
+		case ' ', '-', '+':
+			fragment.Lines = append(fragment.Lines, FragmentLine{Op: op, Line: p.line[1:] + "\n"})
+			if op != '+' {
+				oldLines++
+			}
+			if op != '-' {
+				newLines++
+			}
+		case '\\':
+		default:
+			return nil, fmt.Errorf("%q: invalid hunk line", p.line)
+		}
+		p.next()
+		p.parseNoNewlineAtEndOfFile(fragment)
+	}
+	if oldLines != fragment.OldLines || newLines != fragment.NewLines {
+		return nil, errors.New("truncated hunk")
+	}
+	return fragment, nil
+}
+
+// parseNoNewlineAtEndOfFile removes the trailing newline from the last line of
+// fragment if the current line is a "\ No newline at end of file" marker.
+func (p *patchParser) parseNoNewlineAtEndOfFile(fragment *TextFragment) {
+	if p.eof || !strings.HasPrefix(p.line, `\`) || len(fragment.Lines) == 0 {
+		return
+	}
+	last := &fragment.Lines[len(fragment.Lines)-1]
+	last.Line = strings.TrimSuffix(last.Line, "\n")
+	p.next()
+}
+
+// parseDiffGitLine returns the old and new paths from the arguments of a diff
+// --git line.
+func parseDiffGitLine(s string) (string, string, error) {
+	var oldPath, newPath string
+	if strings.HasPrefix(s, `"`) {
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", fmt.Errorf("%q: invalid diff --git line", s)
+		}
+		oldPath, _ = strconv.Unquote(quoted)
+		newPath, err = unquotePath(strings.TrimPrefix(s[len(quoted):], " "))
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		// Without quoting, the paths are only unambiguous if they are equal,
+		// so look for the space that splits s into two equal paths. Renames
+		// are resolved later from the rename from and rename to lines.
+		i := len(s) / 2
+		if len(s)%2 != 1 || s[i] != ' ' || s[2:i] != s[i+3:] {
+			i = strings.Index(s, " b/")
+			if i == -1 {
+				return "", "", fmt.Errorf("%q: invalid diff --git line", s)
+			}
+		}
+		oldPath, newPath = s[:i], s[i+1:]
+	}
+	if !strings.HasPrefix(oldPath, "a/") || !strings.HasPrefix(newPath, "b/") {
+		return "", "", fmt.Errorf("%q: invalid diff --git line", s)
+	}
+	return oldPath[2:], newPath[2:], nil
+}
+
+// unquotePath returns path with any quoting that git adds for unusual
+// characters removed.
+func unquotePath(path string) (string, error) {
+	if !strings.HasPrefix(path, `"`) {
+		return path, nil
+	}
+	unquoted, err := strconv.Unquote(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid quoted path", path)
+	}
+	return unquoted, nil
+}
+
+func parseMode(s string) (filemode.FileMode, error) {
+	mode, err := filemode.New(s)
+	if err != nil {
+		return filemode.Empty, fmt.Errorf("%s: invalid mode", s)
+	}
+	return mode, nil
+}
+
+func atoiOrDefault(s string, defaultValue int) int {
+	if s == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// splitLinesKeepEnds splits data into lines, each including its trailing
+// newline, if any.
+func splitLinesKeepEnds(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryLiteral(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "empty",
+			data: nil,
+		},
+		{
+			name: "short",
+			data: []byte{0, 1, 2, 3, 0xff},
+		},
+		{
+			name: "long",
+			data: bytes.Repeat([]byte{0, 0x80, 0xff, 'a', 'b', 'c', 0x7f}, 1024),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			require.NoError(t, WriteBinaryLiteral(b, tc.data))
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n\n"), "\n")
+			require.True(t, strings.HasPrefix(lines[0], "literal "))
+			actualData, err := decodeBinaryLiteral(lines[1:], len(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.data), len(actualData))
+			assert.True(t, bytes.Equal(tc.data, actualData))
+		})
+	}
+}
+
+func TestDecodeBinaryLiteral(t *testing.T) {
+	// These are the encodings of an empty file and a short binary file written
+	// by git diff --binary.
+	data, err := decodeBinaryLiteral([]string{"HcmV?d00001"}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	_, err = decodeBinaryLiteral([]string{"HcmV?d00001"}, 1)
+	assert.Error(t, err)
+	data, err = decodeBinaryLiteral([]string{"RcmZQzWJ=1+ODwAV4*(1}1Bd_s"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\x00\x01\x02binary\xff"), data)
+}
+
+func TestParsePatch(t *testing.T) {
+	binaryPatch := &bytes.Buffer{}
+	require.NoError(t, WriteBinaryLiteral(binaryPatch, []byte{0, 1, 2}))
+	require.NoError(t, WriteBinaryLiteral(binaryPatch, nil))
+
+	patch := strings.Join([]string{
+		"Subject: a commit message",
+		"",
+		"diff --git a/.bashrc b/.bashrc",
+		"index 8d3d2ab..3b9a2b6 100644",
+		"--- a/.bashrc",
+		"+++ b/.bashrc",
+		"@@ -1,3 +1,3 @@",
+		" # line 1",
+		"-# line 2",
+		"+# edited line 2",
+		" # line 3",
+		"diff --git a/.profile b/.profile",
+		"deleted file mode 100644",
+		"index 6c2e5a1..0000000",
+		"--- a/.profile",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-# contents of .profile",
+		"diff --git a/.link b/.link",
+		"new file mode 120000",
+		"index 0000000..0b7a8c0",
+		"--- /dev/null",
+		"+++ b/.link",
+		"@@ -0,0 +1 @@",
+		"+.bashrc",
+		`\ No newline at end of file`,
+		"diff --git a/bin/script b/bin/script",
+		"old mode 100644",
+		"new mode 100755",
+		"diff --git a/old name b/new name",
+		"similarity index 100%",
+		"rename from old name",
+		"rename to new name",
+		"diff --git a/binary b/binary",
+		"new file mode 100644",
+		"index 0000000..1a2b3c4",
+		"GIT binary patch",
+		binaryPatch.String(),
+	}, "\n")
+
+	filePatches, err := ParsePatch(strings.NewReader(patch))
+	require.NoError(t, err)
+	require.Len(t, filePatches, 6)
+
+	edit := filePatches[0]
+	assert.Equal(t, ".bashrc", edit.OldPath)
+	assert.Equal(t, ".bashrc", edit.NewPath)
+	assert.Equal(t, filemode.Regular, edit.OldMode)
+	assert.Equal(t, filemode.Regular, edit.NewMode)
+	assert.Equal(t, "8d3d2ab", edit.OldHash)
+	edit.OldHash = ""
+	actual, err := edit.Apply([]byte("# line 1\n# line 2\n# line 3\n# line 4\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "# line 1\n# edited line 2\n# line 3\n# line 4\n", string(actual))
+	_, err = edit.Apply([]byte("# line 1\n# other line 2\n# line 3\n"))
+	assert.Error(t, err)
+
+	del := filePatches[1]
+	assert.True(t, del.IsDelete)
+	assert.Equal(t, filemode.Regular, del.OldMode)
+
+	link := filePatches[2]
+	assert.True(t, link.IsNew)
+	assert.Equal(t, filemode.Symlink, link.NewMode)
+	actual, err = link.Apply(nil)
+	require.NoError(t, err)
+	assert.Equal(t, ".bashrc", string(actual))
+
+	chmod := filePatches[3]
+	assert.Equal(t, filemode.Regular, chmod.OldMode)
+	assert.Equal(t, filemode.Executable, chmod.NewMode)
+	assert.Empty(t, chmod.Fragments)
+
+	rename := filePatches[4]
+	assert.True(t, rename.IsRename)
+	assert.Equal(t, "old name", rename.OldPath)
+	assert.Equal(t, "new name", rename.NewPath)
+
+	binary := filePatches[5]
+	assert.True(t, binary.IsBinary)
+	actual, err = binary.Apply(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2}, actual)
+}
+
+func TestFilePatchApplyChecksHash(t *testing.T) {
+	filePatches, err := ParsePatch(strings.NewReader(strings.Join([]string{
+		"diff --git a/file b/file",
+		"index 0123456..789abcd 100644",
+		"--- a/file",
+		"+++ b/file",
+		"@@ -1 +1 @@",
+		"-# contents of file",
+		"+# edited",
+		"",
+	}, "\n")))
+	require.NoError(t, err)
+	require.Len(t, filePatches, 1)
+	_, err = filePatches[0].Apply([]byte("# contents of file\n"))
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // A ParseError is a parse error.
@@ -65,8 +66,20 @@ type IgnoredStatus struct {
 	Path string
 }
 
+// A BranchStatus is the status of the current branch, parsed from the
+// # branch.* headers.
+type BranchStatus struct {
+	OID      string
+	Head     string
+	Upstream string
+	Ahead    int
+	Behind   int
+}
+
 // A Status is a status.
 type Status struct {
+	Branch          BranchStatus
+	Stash           int
 	Ordinary        []OrdinaryStatus
 	RenamedOrCopied []RenamedOrCopiedStatus
 	Unmerged        []UnmergedStatus
@@ -114,6 +127,12 @@ var (
 		`(.*)` +
 		`$`,
 	)
+	statusPorcelainV2BranchABRegexp = regexp.MustCompile(`` +
+		`^# branch\.ab ` +
+		`\+([0-9]+) ` +
+		`-([0-9]+)` +
+		`$`,
+	)
 	statusPorcelainV2ZUntrackedRegexp = regexp.MustCompile(`` +
 		`^\? ` +
 		`(.*)` +
@@ -132,7 +151,8 @@ func (e ParseError) Error() string {
 
 // ParseStatusPorcelainV2 parses the output of
 //   git status --ignored --porcelain=v2
-// See https://git-scm.com/docs/git-status.
+// See https://git-scm.com/docs/git-status. The headers added by --branch and
+// --show-stash are parsed if present.
 func ParseStatusPorcelainV2(output []byte) (*Status, error) {
 	status := &Status{}
 	s := bufio.NewScanner(bytes.NewReader(output))
@@ -227,7 +247,9 @@ func ParseStatusPorcelainV2(output []byte) (*Status, error) {
 			}
 			status.Ignored = append(status.Ignored, us)
 		case '#':
-			continue
+			if err := parseStatusPorcelainV2Header(status, text); err != nil {
+				return nil, err
+			}
 		default:
 			return nil, ParseError(text)
 		}
@@ -235,7 +257,7 @@ func ParseStatusPorcelainV2(output []byte) (*Status, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	if status.Empty() {
+	if status.Empty() && status.Branch == (BranchStatus{}) && status.Stash == 0 {
 		return nil, nil
 	}
 	return status, nil
@@ -250,3 +272,34 @@ func (s *Status) Empty() bool {
 		len(s.Unmerged) == 0 &&
 		len(s.Untracked) == 0
 }
+
+// parseStatusPorcelainV2Header parses the header line text into status.
+// Unknown headers are ignored.
+func parseStatusPorcelainV2Header(status *Status, text string) error {
+	fields := strings.SplitN(text, " ", 3)
+	if len(fields) != 3 {
+		return nil
+	}
+	switch fields[1] {
+	case "branch.oid":
+		status.Branch.OID = fields[2]
+	case "branch.head":
+		status.Branch.Head = fields[2]
+	case "branch.upstream":
+		status.Branch.Upstream = fields[2]
+	case "branch.ab":
+		m := statusPorcelainV2BranchABRegexp.FindStringSubmatch(text)
+		if m == nil {
+			return ParseError(text)
+		}
+		status.Branch.Ahead, _ = strconv.Atoi(m[1])
+		status.Branch.Behind, _ = strconv.Atoi(m[2])
+	case "stash":
+		stash, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return ParseError(text)
+		}
+		status.Stash = stash
+	}
+	return nil
+}
@@ -161,6 +161,44 @@ func TestParseStatusPorcelainV2(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "branch",
+			outputStr: "" +
+				"# branch.oid 7b3e8f2a2bb8d7b7e1f0e2c3c1a4f6d4e2b1c0a9\n" +
+				"# branch.head master\n" +
+				"# branch.upstream origin/master\n" +
+				"# branch.ab +1 -2\n" +
+				"# stash 3\n",
+			expectedEmpty: true,
+			expectedStatus: &Status{
+				Branch: BranchStatus{
+					OID:      "7b3e8f2a2bb8d7b7e1f0e2c3c1a4f6d4e2b1c0a9",
+					Head:     "master",
+					Upstream: "origin/master",
+					Ahead:    1,
+					Behind:   2,
+				},
+				Stash: 3,
+			},
+		},
+		{
+			name: "branch_initial_detached",
+			outputStr: "" +
+				"# branch.oid (initial)\n" +
+				"# branch.head (detached)\n" +
+				"? chezmoi.go\n",
+			expectedStatus: &Status{
+				Branch: BranchStatus{
+					OID:  "(initial)",
+					Head: "(detached)",
+				},
+				Untracked: []UntrackedStatus{
+					{
+						Path: "chezmoi.go",
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			actualStatus, err := ParseStatusPorcelainV2([]byte(tc.outputStr))
@@ -0,0 +1,395 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// maxSymlinks is the maximum number of symlinks followed when resolving a
+// path.
+const maxSymlinks = 40
+
+var _ vfs.FS = &TreeFS{}
+
+// A TreeFS is a read-only vfs.FS backed by a git tree, for example the tree of
+// a commit. The tree appears at root, paths outside root do not exist, and any
+// methods that modify the FS return an error.
+type TreeFS struct {
+	tree    *object.Tree
+	root    string
+	modTime time.Time
+}
+
+// A treeFileInfo is an os.FileInfo for an entry in a tree.
+type treeFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// NewTreeFS returns a new *TreeFS for tree at root. modTime is the
+// modification time of every entry, typically the commit time.
+func NewTreeFS(tree *object.Tree, root string, modTime time.Time) *TreeFS {
+	return &TreeFS{
+		tree:    tree,
+		root:    filepath.Clean(root),
+		modTime: modTime,
+	}
+}
+
+// Chmod implements os.Chmod.
+func (t *TreeFS) Chmod(name string, mode os.FileMode) error {
+	return permError("Chmod", name)
+}
+
+// Chown implements os.Chown.
+func (t *TreeFS) Chown(name string, uid, gid int) error {
+	return permError("Chown", name)
+}
+
+// Chtimes implements os.Chtimes.
+func (t *TreeFS) Chtimes(name string, atime, mtime time.Time) error {
+	return permError("Chtimes", name)
+}
+
+// Create implements os.Create.
+func (t *TreeFS) Create(name string) (*os.File, error) {
+	return nil, permError("Create", name)
+}
+
+// Glob implements filepath.Glob. Only patterns without meta characters in
+// their directory components are supported.
+func (t *TreeFS) Glob(pattern string) ([]string, error) {
+	dir, filePattern := filepath.Split(pattern)
+	dir = filepath.Clean(dir)
+	if _, err := filepath.Match(filePattern, ""); err != nil {
+		return nil, err
+	}
+	infos, err := t.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	var matches []string
+	for _, info := range infos {
+		if ok, _ := filepath.Match(filePattern, info.Name()); ok {
+			matches = append(matches, filepath.Join(dir, info.Name()))
+		}
+	}
+	return matches, nil
+}
+
+// Lchown implements os.Lchown.
+func (t *TreeFS) Lchown(name string, uid, gid int) error {
+	return permError("Lchown", name)
+}
+
+// Lstat implements os.Lstat.
+func (t *TreeFS) Lstat(name string) (os.FileInfo, error) {
+	relPath, err := t.relPath("Lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return t.lstat("Lstat", name, relPath)
+}
+
+// Mkdir implements os.Mkdir.
+func (t *TreeFS) Mkdir(name string, perm os.FileMode) error {
+	return permError("Mkdir", name)
+}
+
+// Open implements os.Open. It is not supported as a TreeFS has no underlying
+// files.
+func (t *TreeFS) Open(name string) (*os.File, error) {
+	return nil, &os.PathError{Op: "Open", Path: name, Err: errors.New("not supported by git tree")}
+}
+
+// OpenFile implements os.OpenFile. It is not supported as a TreeFS has no
+// underlying files.
+func (t *TreeFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return nil, &os.PathError{Op: "OpenFile", Path: name, Err: errors.New("not supported by git tree")}
+}
+
+// PathSeparator returns the path separator.
+func (t *TreeFS) PathSeparator() rune {
+	return filepath.Separator
+}
+
+// RawPath returns name unchanged.
+func (t *TreeFS) RawPath(name string) (string, error) {
+	return name, nil
+}
+
+// ReadDir implements ioutil.ReadDir.
+func (t *TreeFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	relPath, err := t.resolve("ReadDir", dirname)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := t.subtree("ReadDir", dirname, relPath)
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		// Submodules are not in the repository, so appear empty.
+		return nil, nil
+	}
+	infos := make([]os.FileInfo, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		info, err := t.lstat("ReadDir", filepath.Join(dirname, entry.Name), path.Join(relPath, entry.Name))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// ReadFile implements ioutil.ReadFile.
+func (t *TreeFS) ReadFile(filename string) ([]byte, error) {
+	relPath, err := t.resolve("ReadFile", filename)
+	if err != nil {
+		return nil, err
+	}
+	if relPath == "." {
+		return nil, &os.PathError{Op: "ReadFile", Path: filename, Err: syscall.EISDIR}
+	}
+	entry, err := t.tree.FindEntry(relPath)
+	if err != nil {
+		return nil, notExistError("ReadFile", filename)
+	}
+	if !entry.Mode.IsFile() {
+		return nil, &os.PathError{Op: "ReadFile", Path: filename, Err: syscall.EISDIR}
+	}
+	file, err := t.tree.TreeEntryFile(entry)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
+
+// Readlink implements os.Readlink.
+func (t *TreeFS) Readlink(name string) (string, error) {
+	relPath, err := t.relPath("Readlink", name)
+	if err != nil {
+		return "", err
+	}
+	linkname, ok, err := t.readlink(relPath)
+	switch {
+	case err != nil:
+		return "", notExistError("Readlink", name)
+	case !ok:
+		return "", &os.PathError{Op: "Readlink", Path: name, Err: syscall.EINVAL}
+	default:
+		return linkname, nil
+	}
+}
+
+// Remove implements os.Remove.
+func (t *TreeFS) Remove(name string) error {
+	return permError("Remove", name)
+}
+
+// RemoveAll implements os.RemoveAll.
+func (t *TreeFS) RemoveAll(name string) error {
+	return permError("RemoveAll", name)
+}
+
+// Rename implements os.Rename.
+func (t *TreeFS) Rename(oldpath, newpath string) error {
+	return &os.LinkError{
+		Op:  "Rename",
+		Old: oldpath,
+		New: newpath,
+		Err: syscall.EPERM,
+	}
+}
+
+// Stat implements os.Stat.
+func (t *TreeFS) Stat(name string) (os.FileInfo, error) {
+	relPath, err := t.resolve("Stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := t.lstat("Stat", name, relPath)
+	if err != nil {
+		return nil, err
+	}
+	return &treeFileInfo{
+		name:    filepath.Base(name),
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// Symlink implements os.Symlink.
+func (t *TreeFS) Symlink(oldname, newname string) error {
+	return &os.LinkError{
+		Op:  "Symlink",
+		Old: oldname,
+		New: newname,
+		Err: syscall.EPERM,
+	}
+}
+
+// Truncate implements os.Truncate.
+func (t *TreeFS) Truncate(name string, size int64) error {
+	return permError("Truncate", name)
+}
+
+// WriteFile implements ioutil.WriteFile.
+func (t *TreeFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return permError("WriteFile", filename)
+}
+
+// lstat returns the os.FileInfo for name, whose path relative to the tree is
+// relPath, without following symlinks.
+func (t *TreeFS) lstat(op, name, relPath string) (os.FileInfo, error) {
+	info := &treeFileInfo{
+		name:    filepath.Base(name),
+		mode:    os.ModeDir | 0o755,
+		modTime: t.modTime,
+	}
+	if relPath == "." {
+		return info, nil
+	}
+	entry, err := t.tree.FindEntry(relPath)
+	if err != nil {
+		return nil, notExistError(op, name)
+	}
+	switch entry.Mode {
+	case filemode.Dir, filemode.Submodule:
+		return info, nil
+	case filemode.Executable:
+		info.mode = 0o755
+	case filemode.Symlink:
+		info.mode = os.ModeSymlink | 0o777
+	default:
+		info.mode = 0o644
+	}
+	if info.size, err = t.tree.Size(relPath); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// readlink returns the target of the symlink at relPath and whether relPath
+// is a symlink.
+func (t *TreeFS) readlink(relPath string) (string, bool, error) {
+	if relPath == "." {
+		return "", false, nil
+	}
+	entry, err := t.tree.FindEntry(relPath)
+	if err != nil {
+		return "", false, err
+	}
+	if entry.Mode != filemode.Symlink {
+		return "", false, nil
+	}
+	file, err := t.tree.TreeEntryFile(entry)
+	if err != nil {
+		return "", false, err
+	}
+	linkname, err := file.Contents()
+	return linkname, true, err
+}
+
+// relPath returns the slash-separated path of name relative to the tree.
+func (t *TreeFS) relPath(op, name string) (string, error) {
+	relPath, err := filepath.Rel(t.root, name)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", notExistError(op, name)
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// resolve returns the slash-separated path of name relative to the tree,
+// following any symlinks. Symlinks that point outside the tree do not exist.
+func (t *TreeFS) resolve(op, name string) (string, error) {
+	relPath, err := t.relPath(op, name)
+	if err != nil {
+		return "", err
+	}
+	if relPath == "." {
+		return relPath, nil
+	}
+	components := strings.Split(relPath, "/")
+	resolved := "."
+	for symlinks := 0; len(components) > 0; {
+		component := components[0]
+		components = components[1:]
+		next := path.Join(resolved, component)
+		linkname, ok, err := t.readlink(next)
+		if err != nil {
+			return "", notExistError(op, name)
+		}
+		if !ok {
+			resolved = next
+			continue
+		}
+		symlinks++
+		if symlinks > maxSymlinks {
+			return "", &os.PathError{Op: op, Path: name, Err: syscall.ELOOP}
+		}
+		if path.IsAbs(linkname) {
+			return "", notExistError(op, name)
+		}
+		target := path.Join(resolved, linkname)
+		if target == ".." || strings.HasPrefix(target, "../") {
+			return "", notExistError(op, name)
+		}
+		resolved = "."
+		if target != "." {
+			components = append(strings.Split(target, "/"), components...)
+		}
+	}
+	return resolved, nil
+}
+
+// subtree returns the tree at relPath, or nil if relPath is a submodule.
+func (t *TreeFS) subtree(op, name, relPath string) (*object.Tree, error) {
+	if relPath == "." {
+		return t.tree, nil
+	}
+	entry, err := t.tree.FindEntry(relPath)
+	if err != nil {
+		return nil, notExistError(op, name)
+	}
+	switch entry.Mode {
+	case filemode.Dir:
+		return t.tree.Tree(relPath)
+	case filemode.Submodule:
+		return nil, nil
+	default:
+		return nil, &os.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+	}
+}
+
+func (i *treeFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *treeFileInfo) ModTime() time.Time { return i.modTime }
+func (i *treeFileInfo) Mode() os.FileMode  { return i.mode }
+func (i *treeFileInfo) Name() string       { return i.name }
+func (i *treeFileInfo) Size() int64        { return i.size }
+func (i *treeFileInfo) Sys() interface{}   { return nil }
+
+func notExistError(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+func permError(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: syscall.EPERM}
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+func TestTreeFS(t *testing.T) {
+	s := memory.NewStorage()
+	tree := newTestTree(t, s, []object.TreeEntry{
+		{Name: "dir", Mode: filemode.Dir, Hash: newTestTree(t, s, []object.TreeEntry{
+			{Name: "file", Mode: filemode.Regular, Hash: newTestBlob(t, s, "# contents of dir/file\n")},
+		}).Hash},
+		{Name: "executable", Mode: filemode.Executable, Hash: newTestBlob(t, s, "#!/bin/sh\n")},
+		{Name: "file", Mode: filemode.Regular, Hash: newTestBlob(t, s, "# contents of file\n")},
+		{Name: "link", Mode: filemode.Symlink, Hash: newTestBlob(t, s, "dir")},
+		{Name: "outside", Mode: filemode.Symlink, Hash: newTestBlob(t, s, "../file")},
+	})
+	root := filepath.Join(string(filepath.Separator)+"home", "user", ".local", "share", "chezmoi")
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs := NewTreeFS(tree, root, modTime)
+
+	t.Run("Walk", func(t *testing.T) {
+		actualModes := make(map[string]os.FileMode)
+		require.NoError(t, vfs.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+			require.NoError(t, err)
+			relPath, err := filepath.Rel(root, path)
+			require.NoError(t, err)
+			actualModes[filepath.ToSlash(relPath)] = info.Mode()
+			assert.Equal(t, modTime, info.ModTime())
+			return nil
+		}))
+		assert.Equal(t, map[string]os.FileMode{
+			".":          os.ModeDir | 0o755,
+			"dir":        os.ModeDir | 0o755,
+			"dir/file":   0o644,
+			"executable": 0o755,
+			"file":       0o644,
+			"link":       os.ModeSymlink | 0o777,
+			"outside":    os.ModeSymlink | 0o777,
+		}, actualModes)
+	})
+
+	t.Run("ReadFile", func(t *testing.T) {
+		data, err := fs.ReadFile(filepath.Join(root, "file"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("# contents of file\n"), data)
+
+		data, err = fs.ReadFile(filepath.Join(root, "link", "file"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("# contents of dir/file\n"), data)
+
+		_, err = fs.ReadFile(filepath.Join(root, "dir"))
+		assert.Error(t, err)
+
+		_, err = fs.ReadFile(filepath.Join(root, "missing"))
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = fs.ReadFile(filepath.Join(root, "outside"))
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = fs.ReadFile(filepath.Join(filepath.Dir(root), "file"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Readlink", func(t *testing.T) {
+		linkname, err := fs.Readlink(filepath.Join(root, "link"))
+		require.NoError(t, err)
+		assert.Equal(t, "dir", linkname)
+
+		_, err = fs.Readlink(filepath.Join(root, "file"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := fs.Stat(filepath.Join(root, "link"))
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		assert.Equal(t, "link", info.Name())
+
+		info, err = fs.Stat(filepath.Join(root, "file"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("# contents of file\n")), info.Size())
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.Error(t, fs.WriteFile(filepath.Join(root, "file"), nil, 0o644))
+		assert.Error(t, fs.Mkdir(filepath.Join(root, "newdir"), 0o755))
+		assert.Error(t, fs.RemoveAll(root))
+	})
+}
+
+func newTestBlob(t *testing.T, s *memory.Storage, contents string) plumbing.Hash {
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	require.NoError(t, err)
+	_, err = w.Write([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	hash, err := s.SetEncodedObject(obj)
+	require.NoError(t, err)
+	return hash
+}
+
+func newTestTree(t *testing.T, s *memory.Storage, entries []object.TreeEntry) *object.Tree {
+	obj := s.NewEncodedObject()
+	require.NoError(t, (&object.Tree{Entries: entries}).Encode(obj))
+	hash, err := s.SetEncodedObject(obj)
+	require.NoError(t, err)
+	tree, err := object.GetTree(s, hash)
+	require.NoError(t, err)
+	return tree
+}